@@ -0,0 +1,198 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/kmesiab/chime-ai/ai/provider"
+)
+
+const (
+	// DefaultMaxIterations is the number of model calls allowed per question
+	// when Agent.MaxIterations isn't set
+	DefaultMaxIterations = 5
+
+	// DefaultMaxRetries is the number of failed tool calls the model may
+	// correct per question when Agent.MaxRetries isn't set
+	DefaultMaxRetries = 3
+)
+
+var (
+	// ErrMaxIterations is returned when the model is still calling tools
+	// after the maximum number of iterations
+	ErrMaxIterations = errors.New("maximum number of iterations reached before the model answered")
+
+	// ErrMaxRetries is returned when more tool calls failed than the model
+	// may retry
+	ErrMaxRetries = errors.New("maximum number of retries reached")
+)
+
+// ToolError is a tool failure the model can fix, such as a query with a
+// wrong column name. Instead of ending the run, Result is sent back to the
+// model as the tool result so it can correct the call and try again.
+type ToolError struct {
+	Result string
+	Err    error
+}
+
+func (e *ToolError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+// Tool is a function the model can call
+type Tool interface {
+	// Definition describes the tool to the model
+	Definition() openai.Tool
+
+	// Call runs the tool with the JSON arguments the model supplied and
+	// returns the result sent back to the model
+	Call(ctx context.Context, arguments string) (string, error)
+}
+
+// Agent answers questions by letting the model call tools until it produces
+// a final answer
+type Agent struct {
+	chat  provider.ChatProvider
+	tools []Tool
+
+	// Model is used for the first call of each question and FollowUpModel,
+	// with Temperature and FrequencyPenalty, for the calls after a tool ran
+	Model            string
+	FollowUpModel    string
+	Temperature      float32
+	FrequencyPenalty float32
+
+	// MaxIterations caps the number of model calls per question
+	MaxIterations int
+
+	// MaxRetries caps the number of failed tool calls per question that are
+	// handed back to the model to correct
+	MaxRetries int
+
+	// OnUsage, when set, is called with the token usage of every model call,
+	// including the calls of runs that end in an error
+	OnUsage func(model string, usage openai.Usage)
+}
+
+func New(chat provider.ChatProvider, tools ...Tool) *Agent {
+	return &Agent{
+		chat:             chat,
+		tools:            tools,
+		Model:            openai.GPT4o,
+		FollowUpModel:    openai.GPT4oMini,
+		Temperature:      0.7,
+		FrequencyPenalty: 0.7,
+		MaxIterations:    DefaultMaxIterations,
+		MaxRetries:       DefaultMaxRetries,
+	}
+}
+
+// Run sends the conversation to the model and runs the tools it calls,
+// feeding each result back as a tool message, until the model answers. A
+// ToolError is fed back the same way, up to MaxRetries times. Run returns the
+// answer and the conversation extended with the tool calls, their results and
+// the answer.
+func (a *Agent) Run(ctx context.Context, messages []openai.ChatCompletionMessage) (string, []openai.ChatCompletionMessage, error) {
+	maxIterations := a.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxIterations
+	}
+
+	maxRetries := a.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	var retries int
+
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		request := a.request(messages, iteration)
+
+		resp, err := a.chat.CreateChatCompletion(ctx, request)
+		if err != nil {
+			return "", messages, fmt.Errorf("error creating chat completion: %w", err)
+		}
+
+		if a.OnUsage != nil {
+			model := resp.Model
+			if model == "" {
+				model = request.Model
+			}
+			a.OnUsage(model, resp.Usage)
+		}
+
+		if len(resp.Choices) == 0 {
+			return "", messages, errors.New("the model returned no choices")
+		}
+
+		choice := resp.Choices[0]
+		messages = append(messages, choice.Message)
+
+		if choice.FinishReason != openai.FinishReasonToolCalls && len(choice.Message.ToolCalls) == 0 {
+			return choice.Message.Content, messages, nil
+		}
+
+		for _, call := range choice.Message.ToolCalls {
+			output, err := a.callTool(ctx, call)
+
+			var toolErr *ToolError
+			if errors.As(err, &toolErr) && ctx.Err() == nil {
+				if retries == maxRetries {
+					return "", messages, fmt.Errorf("%w (%d): %w", ErrMaxRetries, maxRetries, err)
+				}
+
+				retries++
+				output = toolErr.Result
+			} else if err != nil {
+				return "", messages, err
+			}
+
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				Content:    output,
+				Name:       call.Function.Name,
+				ToolCallID: call.ID,
+			})
+		}
+	}
+
+	return "", messages, fmt.Errorf("%w (%d)", ErrMaxIterations, maxIterations)
+}
+
+func (a *Agent) request(messages []openai.ChatCompletionMessage, iteration int) openai.ChatCompletionRequest {
+	request := openai.ChatCompletionRequest{
+		Model:    a.Model,
+		Messages: messages,
+	}
+
+	if iteration > 0 {
+		request.Model = a.FollowUpModel
+		request.Temperature = a.Temperature
+		request.FrequencyPenalty = a.FrequencyPenalty
+	}
+
+	for _, tool := range a.tools {
+		request.Tools = append(request.Tools, tool.Definition())
+	}
+
+	return request
+}
+
+func (a *Agent) callTool(ctx context.Context, call openai.ToolCall) (string, error) {
+	for _, tool := range a.tools {
+		if tool.Definition().Function.Name == call.Function.Name {
+			return tool.Call(ctx, call.Function.Arguments)
+		}
+	}
+
+	err := fmt.Errorf("unknown tool %q", call.Function.Name)
+
+	return "", &ToolError{Result: "Error: " + err.Error(), Err: err}
+}
@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// fakeTool records the arguments of each call and returns a canned result
+type fakeTool struct {
+	calls []string
+}
+
+func (t *fakeTool) Definition() openai.Tool {
+	return openai.Tool{
+		Type:     openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{Name: "FakeTool"},
+	}
+}
+
+func (t *fakeTool) Call(_ context.Context, arguments string) (string, error) {
+	t.calls = append(t.calls, arguments)
+	return `[{"total": 42}]`, nil
+}
+
+// fakeProvider serves the given responses to successive chat completion
+// requests, repeating the last one, and records every request it receives
+type fakeProvider struct {
+	responses []openai.ChatCompletionResponse
+	requests  []openai.ChatCompletionRequest
+}
+
+func (p *fakeProvider) CreateChatCompletion(_ context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	index := len(p.requests)
+	if index >= len(p.responses) {
+		index = len(p.responses) - 1
+	}
+	p.requests = append(p.requests, request)
+
+	return p.responses[index], nil
+}
+
+func toolCallResponse(id, arguments string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{
+			FinishReason: openai.FinishReasonToolCalls,
+			Message: openai.ChatCompletionMessage{
+				Role: openai.ChatMessageRoleAssistant,
+				ToolCalls: []openai.ToolCall{{
+					ID:       id,
+					Type:     openai.ToolTypeFunction,
+					Function: openai.FunctionCall{Name: "FakeTool", Arguments: arguments},
+				}},
+			},
+		}},
+	}
+}
+
+func answerResponse(content string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{
+			FinishReason: openai.FinishReasonStop,
+			Message: openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: content,
+			},
+		}},
+	}
+}
+
+func TestRunLoopsUntilAnswer(t *testing.T) {
+	chat := &fakeProvider{responses: []openai.ChatCompletionResponse{
+		toolCallResponse("call_1", `{"sql":"SELECT 1"}`),
+		toolCallResponse("call_2", `{"sql":"SELECT 2"}`),
+		answerResponse("You spent $42."),
+	}}
+
+	tool := &fakeTool{}
+	agent := New(chat, tool)
+
+	question := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "How much?"}}
+	answer, messages, err := agent.Run(context.Background(), question)
+	if err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+
+	if answer != "You spent $42." {
+		t.Errorf("unexpected answer %q", answer)
+	}
+
+	if len(tool.calls) != 2 || tool.calls[1] != `{"sql":"SELECT 2"}` {
+		t.Errorf("unexpected tool calls %v", tool.calls)
+	}
+
+	// question, two rounds of tool call and result, answer
+	if len(messages) != 6 {
+		t.Fatalf("expected 6 messages, got %d", len(messages))
+	}
+
+	result := messages[2]
+	if result.Role != openai.ChatMessageRoleTool || result.ToolCallID != "call_1" {
+		t.Errorf("expected a tool message for call_1, got %+v", result)
+	}
+
+	if len(chat.requests) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(chat.requests))
+	}
+
+	if chat.requests[0].Model != agent.Model || chat.requests[1].Model != agent.FollowUpModel {
+		t.Errorf("unexpected models %q and %q", chat.requests[0].Model, chat.requests[1].Model)
+	}
+
+	if got := chat.requests[2].Messages[4]; got.ToolCallID != "call_2" {
+		t.Errorf("expected the last request to include the second tool result, got %+v", got)
+	}
+}
+
+func TestRunStopsAtMaxIterations(t *testing.T) {
+	chat := &fakeProvider{responses: []openai.ChatCompletionResponse{toolCallResponse("call", `{}`)}}
+
+	agent := New(chat, &fakeTool{})
+	agent.MaxIterations = 3
+
+	_, _, err := agent.Run(context.Background(), nil)
+	if !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("expected ErrMaxIterations, got %v", err)
+	}
+
+	if len(chat.requests) != 3 {
+		t.Errorf("expected 3 requests, got %d", len(chat.requests))
+	}
+}
+
+func TestRunUnknownTool(t *testing.T) {
+	chat := &fakeProvider{responses: []openai.ChatCompletionResponse{toolCallResponse("call", `{}`)}}
+
+	if _, _, err := New(chat).Run(context.Background(), nil); err == nil {
+		t.Fatal("expected an error for an unknown tool")
+	}
+}
+
+// failingTool fails with a ToolError on every call
+type failingTool struct {
+	fakeTool
+}
+
+func (t *failingTool) Call(_ context.Context, arguments string) (string, error) {
+	t.calls = append(t.calls, arguments)
+	return "", &ToolError{Result: "no such column: amount", Err: errors.New("no such column: amount")}
+}
+
+func TestRunFeedsToolErrorsBack(t *testing.T) {
+	chat := &fakeProvider{responses: []openai.ChatCompletionResponse{
+		toolCallResponse("call_1", `{"sql":"SELECT amount"}`),
+		answerResponse("Sorry, I couldn't query that."),
+	}}
+
+	answer, messages, err := New(chat, &failingTool{}).Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+
+	if answer != "Sorry, I couldn't query that." {
+		t.Errorf("unexpected answer %q", answer)
+	}
+
+	if result := messages[1]; result.Role != openai.ChatMessageRoleTool || result.Content != "no such column: amount" {
+		t.Errorf("expected the error as the tool result, got %+v", result)
+	}
+}
+
+func TestRunStopsAtMaxRetries(t *testing.T) {
+	chat := &fakeProvider{responses: []openai.ChatCompletionResponse{toolCallResponse("call", `{}`)}}
+
+	tool := &failingTool{}
+	agent := New(chat, tool)
+	agent.MaxIterations = 10
+	agent.MaxRetries = 2
+
+	_, _, err := agent.Run(context.Background(), nil)
+	if !errors.Is(err, ErrMaxRetries) {
+		t.Fatalf("expected ErrMaxRetries, got %v", err)
+	}
+
+	// two retries after the first failure
+	if len(tool.calls) != 3 {
+		t.Errorf("expected 3 tool calls, got %d", len(tool.calls))
+	}
+}
+
+func TestRunReportsUsage(t *testing.T) {
+	first := toolCallResponse("call", `{}`)
+	first.Model = "gpt-4o-2024-08-06"
+	first.Usage = openai.Usage{PromptTokens: 100, CompletionTokens: 10}
+
+	second := answerResponse("done")
+	second.Usage = openai.Usage{PromptTokens: 200, CompletionTokens: 20}
+
+	chat := &fakeProvider{responses: []openai.ChatCompletionResponse{first, second}}
+
+	agent := New(chat, &fakeTool{})
+
+	var models []string
+	var prompt int
+	agent.OnUsage = func(model string, usage openai.Usage) {
+		models = append(models, model)
+		prompt += usage.PromptTokens
+	}
+
+	if _, _, err := agent.Run(context.Background(), nil); err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+
+	// The response's model wins, the request's is the fallback
+	if len(models) != 2 || models[0] != "gpt-4o-2024-08-06" || models[1] != agent.FollowUpModel {
+		t.Errorf("unexpected models %v", models)
+	}
+
+	if prompt != 300 {
+		t.Errorf("expected 300 prompt tokens, got %d", prompt)
+	}
+}
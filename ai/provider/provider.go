@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ChatProvider is a chat model backend. Requests and responses use the
+// OpenAI chat completion types, including tool definitions and tool calls,
+// since every supported backend speaks that API.
+type ChatProvider interface {
+	CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+}
+
+// OpenAI talks to the OpenAI API or to any server implementing the same chat
+// completions endpoint, such as Ollama or the llama.cpp server
+type OpenAI struct {
+	client *openai.Client
+}
+
+// NewOpenAI creates a provider for the OpenAI API, or for the
+// OpenAI-compatible server at baseURL when it isn't empty. Local servers
+// usually ignore the API key, so it may be empty when baseURL is set.
+func NewOpenAI(apiKey, baseURL string) *OpenAI {
+	config := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		config.BaseURL = baseURL
+	}
+
+	return &OpenAI{client: openai.NewClientWithConfig(config)}
+}
+
+func (p *OpenAI) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	return p.client.CreateChatCompletion(ctx, request)
+}
@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestOpenAIBaseURL(t *testing.T) {
+	var (
+		path    string
+		request openai.ChatCompletionRequest
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "hello"},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	var chat ChatProvider = NewOpenAI("", server.URL+"/v1")
+
+	resp, err := chat.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    "llama3.1",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion returned an error: %v", err)
+	}
+
+	if path != "/v1/chat/completions" {
+		t.Errorf("expected a request to /v1/chat/completions, got %s", path)
+	}
+
+	if request.Model != "llama3.1" {
+		t.Errorf("expected the model to be passed through, got %q", request.Model)
+	}
+
+	if resp.Choices[0].Message.Content != "hello" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
@@ -0,0 +1,76 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/kmesiab/chime-ai/ai/agent"
+	"github.com/kmesiab/chime-ai/database"
+)
+
+// Arguments are the arguments the model passes when calling the tool
+type Arguments struct {
+	SQL string `json:"sql"`
+}
+
+// Handler runs the queries the model writes in a read-only sandbox
+type Handler struct {
+	sandbox     *database.Sandbox
+	description string
+
+	// ResultRows caps the number of rows sent back to the model
+	ResultRows int
+
+	// OnQuery, when set, is called with each query before it runs
+	OnQuery func(sql string)
+}
+
+// NewHandler creates the tool with the description built by Describe
+func NewHandler(sandbox *database.Sandbox, description string) *Handler {
+	return &Handler{sandbox: sandbox, description: description, ResultRows: DefaultResultRows}
+}
+
+func (h *Handler) Definition() openai.Tool {
+	return NewTool(h.description)
+}
+
+// Call runs the query in arguments and returns the rows as CSV, cut off at
+// ResultRows with column statistics standing in for the rest. Queries the
+// sandbox refuses or SQLite fails come back as an *agent.ToolError holding
+// the *database.QueryError, so the model sees the error and its SQL and can
+// correct the query.
+func (h *Handler) Call(ctx context.Context, arguments string) (string, error) {
+	var args Arguments
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		err = fmt.Errorf("invalid tool arguments: %w", err)
+		return "", &agent.ToolError{Result: "Error: " + err.Error(), Err: err}
+	}
+
+	if h.OnQuery != nil {
+		h.OnQuery(args.SQL)
+	}
+
+	result, err := h.sandbox.Query(ctx, args.SQL)
+	if err != nil {
+		return "", &agent.ToolError{Result: queryErrorResult(err), Err: err}
+	}
+
+	return formatResult(result, h.ResultRows), nil
+}
+
+// queryErrorResult tells the model why its query failed, including the query
+// itself, so it can write a corrected one
+func queryErrorResult(err error) string {
+	var queryErr *database.QueryError
+	if !errors.As(err, &queryErr) {
+		return "The query failed: " + err.Error()
+	}
+
+	data, _ := json.Marshal(map[string]*database.QueryError{"error": queryErr})
+
+	return fmt.Sprintf("The query failed, correct it and try again.\n%s", data)
+}
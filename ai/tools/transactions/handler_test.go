@@ -0,0 +1,71 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/kmesiab/chime-ai/ai/agent"
+	"github.com/kmesiab/chime-ai/database"
+)
+
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&database.Transaction{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	if err := db.Create(&database.Transaction{Description: "Coffee", Type: "Purchase", Amount: -450, NetAmount: -450}).Error; err != nil {
+		t.Fatalf("failed to seed database: %v", err)
+	}
+
+	return NewHandler(database.NewSandbox(db), "")
+}
+
+func TestHandlerCall(t *testing.T) {
+	output, err := newTestHandler(t).Call(context.Background(), `{"sql": "SELECT description FROM transactions"}`)
+	if err != nil {
+		t.Fatalf("Call returned an error: %v", err)
+	}
+
+	if output != "description\nCoffee\n" {
+		t.Errorf("unexpected output %s", output)
+	}
+}
+
+func TestHandlerCallErrors(t *testing.T) {
+	tests := []struct {
+		arguments string
+		want      []string
+	}{
+		{`{"sql": "SELECT amount FROM transactions"}`, []string{"no such column: amount", `"sql":"SELECT amount FROM transactions"`}},
+		{`{"sql": "DROP TABLE transactions"}`, []string{`"code":"rejected"`}},
+		{`not json`, []string{"invalid tool arguments"}},
+	}
+
+	for _, tt := range tests {
+		_, err := newTestHandler(t).Call(context.Background(), tt.arguments)
+
+		var toolErr *agent.ToolError
+		if !errors.As(err, &toolErr) {
+			t.Errorf("%s: expected an *agent.ToolError, got %v", tt.arguments, err)
+			continue
+		}
+
+		for _, want := range tt.want {
+			if !strings.Contains(toolErr.Result, want) {
+				t.Errorf("%s: result %q is missing %q", tt.arguments, toolErr.Result, want)
+			}
+		}
+	}
+}
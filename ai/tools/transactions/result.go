@@ -0,0 +1,177 @@
+package transactions
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+// DefaultResultRows is the number of rows sent to the model when
+// Handler.ResultRows isn't set
+const DefaultResultRows = 50
+
+// columnStats summarizes a column of a truncated result
+type columnStats struct {
+	count    int
+	min, max interface{}
+	sum      float64
+	numeric  bool
+}
+
+// formatResult encodes a query result as CSV, which costs far fewer tokens
+// than JSON. Past maxRows the rows are cut off, and the model is told so and
+// given the count, min, max and sum of every column instead. The same
+// statistics are given when the sandbox stopped fetching rows early.
+func formatResult(result *database.QueryResult, maxRows int) string {
+	if maxRows <= 0 {
+		maxRows = DefaultResultRows
+	}
+
+	rows := result.Rows
+	truncated := len(rows) > maxRows
+	if truncated {
+		rows = rows[:maxRows]
+	}
+
+	var b strings.Builder
+
+	if len(result.Rows) == 0 {
+		b.WriteString("The query returned no rows.\n")
+	} else {
+		b.WriteString(encodeCSV(result.Columns, rows))
+	}
+
+	switch {
+	case truncated && result.Truncated:
+		fmt.Fprintf(&b, "\nTRUNCATED: the query returned more than %d rows, only the first %d are shown. "+
+			"The statistics below cover the first %d rows. Aggregate in SQL for exact totals.\n",
+			len(result.Rows), len(rows), len(result.Rows))
+	case truncated:
+		fmt.Fprintf(&b, "\nTRUNCATED: the query returned %d rows, only the first %d are shown. "+
+			"The statistics below cover all %d rows.\n",
+			len(result.Rows), len(rows), len(result.Rows))
+	case result.Truncated:
+		fmt.Fprintf(&b, "\nTRUNCATED: the query returned more than %d rows, only the first %d are shown. "+
+			"The statistics below cover the first %d rows. Aggregate in SQL for exact totals.\n",
+			len(result.Rows), len(rows), len(rows))
+	}
+
+	if truncated || result.Truncated {
+		b.WriteString("\nColumn statistics:\n")
+		b.WriteString(encodeStats(result.Columns, result.Rows))
+	}
+
+	return b.String()
+}
+
+func encodeCSV(columns []string, rows [][]interface{}) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	_ = w.Write(columns)
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = formatValue(value)
+		}
+		_ = w.Write(record)
+	}
+
+	w.Flush()
+
+	return buf.String()
+}
+
+// encodeStats computes the statistics of every column as CSV. Sums are only
+// given for numeric columns.
+func encodeStats(columns []string, rows [][]interface{}) string {
+	stats := make([]columnStats, len(columns))
+	for i := range stats {
+		stats[i].numeric = true
+	}
+
+	for _, row := range rows {
+		for i, value := range row {
+			if value == nil {
+				continue
+			}
+
+			s := &stats[i]
+			s.count++
+
+			number, isNumber := toFloat(value)
+			if !isNumber {
+				s.numeric = false
+			} else {
+				s.sum += number
+			}
+
+			if s.min == nil || compareValues(value, s.min) < 0 {
+				s.min = value
+			}
+			if s.max == nil || compareValues(value, s.max) > 0 {
+				s.max = value
+			}
+		}
+	}
+
+	records := [][]string{{"column", "count", "min", "max", "sum"}}
+	for i, s := range stats {
+		sum := ""
+		if s.numeric && s.count > 0 {
+			sum = formatValue(s.sum)
+		}
+
+		records = append(records, []string{
+			columns[i],
+			fmt.Sprint(s.count),
+			formatValue(s.min),
+			formatValue(s.max),
+			sum,
+		})
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.WriteAll(records)
+
+	return buf.String()
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// compareValues orders numbers numerically and everything else, dates
+// included, by its formatted text
+func compareValues(a, b interface{}) int {
+	x, aNumber := toFloat(a)
+	y, bNumber := toFloat(b)
+
+	if aNumber && bNumber {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(formatValue(a), formatValue(b))
+}
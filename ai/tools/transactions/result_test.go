@@ -0,0 +1,74 @@
+package transactions
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+func TestFormatResult(t *testing.T) {
+	result := &database.QueryResult{
+		Columns: []string{"date", "description", "amount"},
+		Rows: [][]interface{}{
+			{time.Date(2024, 7, 19, 0, 0, 0, 0, time.UTC), "Coffee, Inc.", -4.5},
+			{time.Date(2024, 7, 20, 0, 0, 0, 0, time.UTC), "Payroll", 1500.0},
+		},
+	}
+
+	want := "date,description,amount\n2024-07-19,\"Coffee, Inc.\",-4.5\n2024-07-20,Payroll,1500\n"
+	if got := formatResult(result, 10); got != want {
+		t.Errorf("unexpected result:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatResultTruncated(t *testing.T) {
+	result := &database.QueryResult{Columns: []string{"description", "amount", "transfer_id"}}
+	for i := 1; i <= 5; i++ {
+		result.Rows = append(result.Rows, []interface{}{"Coffee", float64(-i), nil})
+	}
+
+	got := formatResult(result, 2)
+
+	for _, want := range []string{
+		"description,amount,transfer_id\nCoffee,-1,\nCoffee,-2,\n\n",
+		"TRUNCATED: the query returned 5 rows, only the first 2 are shown",
+		"column,count,min,max,sum\n",
+		"description,5,Coffee,Coffee,\n",
+		"amount,5,-5,-1,-15\n",
+		"transfer_id,0,,,\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("result is missing %q:\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(got, "Coffee,-3") {
+		t.Errorf("result includes rows past the cap:\n%s", got)
+	}
+}
+
+func TestFormatResultSandboxTruncated(t *testing.T) {
+	result := &database.QueryResult{
+		Columns:   []string{"amount"},
+		Rows:      [][]interface{}{{int64(1)}, {int64(2)}},
+		Truncated: true,
+	}
+
+	got := formatResult(result, 10)
+	if !strings.Contains(got, "TRUNCATED: the query returned more than 2 rows") {
+		t.Errorf("expected a truncation notice:\n%s", got)
+	}
+
+	if !strings.Contains(got, "Column statistics:\ncolumn,count,min,max,sum\namount,2,1,2,3\n") {
+		t.Errorf("expected statistics of the fetched rows:\n%s", got)
+	}
+}
+
+func TestFormatResultEmpty(t *testing.T) {
+	got := formatResult(&database.QueryResult{Columns: []string{"amount"}}, 10)
+	if got != "The query returned no rows.\n" {
+		t.Errorf("unexpected result %q", got)
+	}
+}
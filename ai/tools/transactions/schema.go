@@ -0,0 +1,232 @@
+package transactions
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+// RedactedValue replaces sample values in redacted columns
+const RedactedValue = "[redacted]"
+
+// DescribeOptions controls what Describe reveals about the data
+type DescribeOptions struct {
+	// SampleRows is the number of sample rows to include, zero for none
+	SampleRows int
+
+	// ValueColumns are the columns whose distinct values are listed, so the
+	// model knows what to filter on
+	ValueColumns []string
+
+	// Redact are the columns whose values are replaced by RedactedValue in
+	// the sample rows and value lists
+	Redact []string
+}
+
+// DefaultDescribeOptions lists the transaction types and categories and
+// shows one sample row per type with the descriptions and merchants, which
+// name the places the user shops at, redacted
+var DefaultDescribeOptions = DescribeOptions{
+	SampleRows:   8,
+	ValueColumns: []string{"type", "category"},
+	Redact:       []string{"description", "merchant"},
+}
+
+// column is a column of the ledger view as reported by SQLite
+type column struct {
+	Name string
+	Type string
+}
+
+// Describe builds the tool description from the live database: the columns
+// of the ledger view, the distinct values of opts.ValueColumns and a few
+// sample rows. Schema changes reach the model without touching this package.
+func Describe(db *gorm.DB, opts DescribeOptions) (string, error) {
+	var columns []column
+	if err := db.Raw("SELECT name, type FROM pragma_table_info(?)", database.LedgerView).Scan(&columns).Error; err != nil {
+		return "", fmt.Errorf("failed to read the %s view's columns: %w", database.LedgerView, err)
+	}
+
+	if len(columns) == 0 {
+		return "", fmt.Errorf("the database has no %s view, run chime-ai import or chime-ai db migrate to create it", database.LedgerView)
+	}
+
+	redacted := map[string]bool{}
+	for _, name := range opts.Redact {
+		redacted[strings.ToLower(name)] = true
+	}
+
+	samples, err := sampleRows(db, opts.SampleRows)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	b.WriteString(toolInstructions)
+	fmt.Fprintf(&b, "\n\nQuery the %s view, which presents amounts in dollars. Its schema is:\n", database.LedgerView)
+	fmt.Fprintf(&b, "create view %s\n(\n", database.LedgerView)
+	for i, c := range columns {
+		separator := ","
+		if i == len(columns)-1 {
+			separator = ""
+		}
+		fmt.Fprintf(&b, "\t%s %s%s\n", c.Name, columnType(c, i, samples), separator)
+	}
+	b.WriteString(");\n")
+
+	for _, name := range opts.ValueColumns {
+		if !hasColumn(columns, name) || redacted[strings.ToLower(name)] {
+			continue
+		}
+
+		var values []string
+		if err := db.Raw(fmt.Sprintf("SELECT DISTINCT %q FROM %s WHERE %q IS NOT NULL ORDER BY 1", name, database.LedgerView, name)).
+			Scan(&values).Error; err != nil {
+			return "", fmt.Errorf("failed to list the values of %s: %w", name, err)
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\nValues of %s are:\n", name)
+		for _, value := range values {
+			fmt.Fprintf(&b, "\t%s\n", value)
+		}
+	}
+
+	if len(samples) > 0 {
+		b.WriteString("\nSample rows:\n")
+		b.WriteString(formatSamples(columns, samples, redacted))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(toolNotes)
+	b.WriteString("\n")
+
+	return b.String(), nil
+}
+
+// sampleRows picks the latest transaction of each type, so every type is
+// represented, up to n rows
+func sampleRows(db *gorm.DB, n int) ([][]interface{}, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	query := fmt.Sprintf(`SELECT * FROM %[1]s
+		WHERE id IN (SELECT max(id) FROM %[1]s GROUP BY type)
+		ORDER BY date LIMIT ?`, database.LedgerView)
+
+	rows, err := db.Raw(query, n).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sample rows: %w", err)
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var samples [][]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(names))
+		pointers := make([]interface{}, len(values))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to read sample rows: %w", err)
+		}
+
+		samples = append(samples, values)
+	}
+
+	return samples, rows.Err()
+}
+
+// columnType is the declared type of the column, or for computed view
+// columns, which have none, the type of its sample values
+func columnType(c column, index int, samples [][]interface{}) string {
+	if c.Type != "" {
+		return strings.ToLower(c.Type)
+	}
+
+	for _, row := range samples {
+		switch row[index].(type) {
+		case int64, bool:
+			return "integer"
+		case float64:
+			return "real"
+		case string, []byte:
+			return "text"
+		case time.Time:
+			return "datetime"
+		}
+	}
+
+	return "numeric"
+}
+
+func hasColumn(columns []column, name string) bool {
+	for _, c := range columns {
+		if strings.EqualFold(c.Name, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatSamples renders the sample rows as CSV with a header line
+func formatSamples(columns []column, samples [][]interface{}, redacted map[string]bool) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.Name
+	}
+	_ = w.Write(header)
+
+	for _, row := range samples {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if i < len(columns) && redacted[strings.ToLower(columns[i].Name)] && value != nil {
+				record[i] = RedactedValue
+			} else {
+				record[i] = formatValue(value)
+			}
+		}
+		_ = w.Write(record)
+	}
+
+	w.Flush()
+
+	return buf.String()
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format("2006-01-02")
+	default:
+		return fmt.Sprint(v)
+	}
+}
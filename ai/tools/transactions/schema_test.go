@@ -0,0 +1,125 @@
+package transactions
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+func newLedgerDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&database.Account{}, &database.Merchant{}, &database.MerchantRule{},
+		&database.Category{}, &database.CategoryRule{}, &database.Transaction{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	if err := database.CreateLedgerView(db); err != nil {
+		t.Fatalf("failed to create ledger view: %v", err)
+	}
+
+	date := time.Date(2024, 7, 19, 0, 0, 0, 0, time.UTC)
+	for _, tx := range []database.Transaction{
+		{Date: date, Description: "Whalewatch Tours", Type: "Purchase", Amount: -27418, NetAmount: -27418},
+		{Date: date, Description: "Notion Labs, Inc.", Type: "Purchase", Amount: -1103, NetAmount: -1103},
+		{Date: date, Description: "Payroll", Type: "Deposit", Amount: 150000, NetAmount: 150000},
+	} {
+		tx.Currency = database.DefaultCurrency
+		if err := db.Create(&tx).Error; err != nil {
+			t.Fatalf("failed to seed database: %v", err)
+		}
+	}
+
+	if _, err := database.AssignMerchants(db, false); err != nil {
+		t.Fatalf("failed to assign merchants: %v", err)
+	}
+
+	for _, rule := range []database.CategoryRule{
+		{Type: "Deposit", Category: "Income"},
+		{Pattern: "notion", Category: "Subscriptions"},
+	} {
+		if err := database.AddCategoryRule(db, &rule); err != nil {
+			t.Fatalf("failed to add category rule: %v", err)
+		}
+	}
+
+	if _, err := database.AssignCategories(db, false); err != nil {
+		t.Fatalf("failed to assign categories: %v", err)
+	}
+
+	return db
+}
+
+func TestDescribe(t *testing.T) {
+	description, err := Describe(newLedgerDB(t), DefaultDescribeOptions)
+	if err != nil {
+		t.Fatalf("Describe returned an error: %v", err)
+	}
+
+	for _, want := range []string{
+		"create view ledger",
+		"\tdescription text,",
+		"\tamount real,",
+		"\taccount text,",
+		"Values of type are:\n\tDeposit\n\tPurchase\n",
+		"Values of category are:\n\tIncome\n\tSubscriptions\n",
+		"id,date,description,merchant,category,type,amount,",
+		"3,2024-07-19," + RedactedValue + "," + RedactedValue + ",Income,Deposit,1500,",
+		"2,2024-07-19," + RedactedValue + "," + RedactedValue + ",Subscriptions,Purchase,-11.03,",
+		"internal_transfer = 0",
+	} {
+		if !strings.Contains(description, want) {
+			t.Errorf("description is missing %q:\n%s", want, description)
+		}
+	}
+
+	// One sample per type, and no merchant names
+	for _, unwanted := range []string{"Whalewatch", "Notion", "Payroll"} {
+		if strings.Contains(description, unwanted) {
+			t.Errorf("description leaks %q:\n%s", unwanted, description)
+		}
+	}
+}
+
+func TestDescribeWithoutRedactionOrSamples(t *testing.T) {
+	db := newLedgerDB(t)
+
+	description, err := Describe(db, DescribeOptions{SampleRows: 8})
+	if err != nil {
+		t.Fatalf("Describe returned an error: %v", err)
+	}
+
+	if !strings.Contains(description, "Notion Labs, Inc.") {
+		t.Errorf("expected unredacted descriptions:\n%s", description)
+	}
+
+	description, err = Describe(db, DescribeOptions{})
+	if err != nil {
+		t.Fatalf("Describe returned an error: %v", err)
+	}
+
+	if strings.Contains(description, "Sample rows") {
+		t.Errorf("expected no sample rows:\n%s", description)
+	}
+}
+
+func TestDescribeWithoutLedger(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if _, err := Describe(db, DefaultDescribeOptions); err == nil {
+		t.Fatal("expected an error for a database without the ledger view")
+	}
+}
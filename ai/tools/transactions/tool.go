@@ -6,62 +6,45 @@ import (
 )
 
 const ToolName = "TransactionsTool"
-const ToolDescription = `Given the user's question, construct a sqlite query to retrieve a dataset to make
-an informed response
-		
-		The table schema is:
-		create table transactions
-				(
-					id          integer primary key,
-					date        datetime,
-					description text,
-					type        text,
-					amount      real,
-					net_amount  real,
-					settle_date datetime
-				);
 
-		Categories are:
-	    Transfer
-		Purchase
-		Direct Debit
-		Fee
-		ATM Withdrawal
-		Deposit
-		Round Up
+// toolInstructions opens the tool description. The schema, column values and
+// sample rows generated by Describe follow it.
+const toolInstructions = `Given the user's question, construct a sqlite query to retrieve a dataset to make
+an informed response`
 
-		Sample rows:
-		8,2024-07-19 00:00:00+00:00,Islandadv.Whalewatch,Purchase,-274.18,-274.18,2024-07-20 00:00:00+00:00
-		9,2024-07-19 00:00:00+00:00,Transfer from Chime Savings Account,Transfer,275,275,2024-07-19 00:00:00+00:00
-		10,2024-07-19 00:00:00+00:00,"Supermaven, Inc.",Purchase,-10,-10,2024-07-20 00:00:00+00:00
-		11,2024-07-19 00:00:00+00:00,"Notion Labs, Inc.",Purchase,-11.03,-11.03,2024-07-20 00:00:00+00:00
-	
-		Notes: 
-		Descriptions can vary despite being the same merchant.  When constructing queries, consider
-	    using flexible matching.
-`
+// toolNotes closes the tool description
+const toolNotes = `Notes:
+Descriptions of the same merchant vary ("AMZN Mktp US*2K4", "AMAZON.COM*MB1LX"), merchant
+holds the cleaned up name they share ("Amazon").  Group and filter by merchant rather than
+description, and match it case-insensitively since the name may differ slightly from the user's.
+category says what the money went on or came from (Dining, Groceries, Income...); use it for
+questions about kinds of spending.  Transactions no rule categorizes have a NULL category.
+Transactions come from several accounts (Checking, Savings, Credit Builder...).  Money
+moved between the user's own accounts appears once in each account with internal_transfer = 1
+and transfer_id pointing at the other side.  Exclude internal transfers (internal_transfer = 0)
+when summarizing spending or income so they aren't double counted.`
 
 var toolParams = jsonschema.Definition{
 	Type: jsonschema.Object,
 	Properties: map[string]jsonschema.Definition{
 		"sql": {
 			Type:        jsonschema.String,
-			Description: ToolDescription,
+			Description: "A single sqlite SELECT statement against the ledger view",
 		},
 	},
 	Required: []string{"sql"},
 }
 
-var functionDefinition = openai.FunctionDefinition{
-	Name:        ToolName,
-	Description: ToolDescription,
-	Strict:      false,
-	Parameters:  toolParams,
-}
-
-func NewTool() openai.Tool {
+// NewTool defines the tool for the model. The description, built by
+// Describe, tells the model what the ledger view holds.
+func NewTool(description string) openai.Tool {
 	return openai.Tool{
-		Type:     openai.ToolTypeFunction,
-		Function: &functionDefinition,
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        ToolName,
+			Description: description,
+			Strict:      false,
+			Parameters:  toolParams,
+		},
 	}
 }
@@ -0,0 +1,167 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Price is what a model charges in dollars per million tokens
+type Price struct {
+	Prompt     float64 `json:"prompt" yaml:"prompt"`
+	Completion float64 `json:"completion" yaml:"completion"`
+}
+
+// PriceTable maps model names to prices. A model matches the longest name
+// it starts with, so "gpt-4o-2024-08-06" is priced as "gpt-4o".
+type PriceTable map[string]Price
+
+// DefaultPrices are OpenAI's list prices at the time of writing. Local
+// models aren't listed; add them with a price of zero to hide the "cost
+// unknown" note.
+var DefaultPrices = PriceTable{
+	"gpt-4o":       {Prompt: 2.50, Completion: 10.00},
+	"gpt-4o-mini":  {Prompt: 0.15, Completion: 0.60},
+	"gpt-4.1":      {Prompt: 2.00, Completion: 8.00},
+	"gpt-4.1-mini": {Prompt: 0.40, Completion: 1.60},
+}
+
+// LoadPrices reads a JSON price table, such as
+// {"gpt-4o": {"prompt": 2.5, "completion": 10}}
+func LoadPrices(path string) (PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var prices PriceTable
+	if err := json.Unmarshal(data, &prices); err != nil {
+		return nil, fmt.Errorf("invalid price table %s: %w", path, err)
+	}
+
+	return prices, nil
+}
+
+// Merge returns a new table with the prices of other added to, or replacing,
+// the prices of t
+func (t PriceTable) Merge(other PriceTable) PriceTable {
+	merged := PriceTable{}
+	for model, price := range t {
+		merged[model] = price
+	}
+	for model, price := range other {
+		merged[model] = price
+	}
+
+	return merged
+}
+
+// Cost estimates the dollar cost of a model call. It reports false when the
+// model isn't in the table.
+func (t PriceTable) Cost(model string, usage openai.Usage) (float64, bool) {
+	var (
+		match string
+		price Price
+		found bool
+	)
+
+	for name, p := range t {
+		if strings.HasPrefix(model, name) && len(name) > len(match) {
+			match, price, found = name, p, true
+		}
+	}
+
+	if !found {
+		return 0, false
+	}
+
+	return (float64(usage.PromptTokens)*price.Prompt + float64(usage.CompletionTokens)*price.Completion) / 1e6, true
+}
+
+// Totals adds up the usage of several model calls
+type Totals struct {
+	Calls            int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+
+	// Unpriced lists the models that had no price, so Cost leaves them out
+	Unpriced []string
+}
+
+func (t *Totals) add(model string, usage openai.Usage, cost float64, priced bool) {
+	t.Calls++
+	t.PromptTokens += usage.PromptTokens
+	t.CompletionTokens += usage.CompletionTokens
+	t.Cost += cost
+
+	if !priced {
+		for _, unpriced := range t.Unpriced {
+			if unpriced == model {
+				return
+			}
+		}
+		t.Unpriced = append(t.Unpriced, model)
+		sort.Strings(t.Unpriced)
+	}
+}
+
+func (t Totals) String() string {
+	s := fmt.Sprintf("%d prompt + %d completion tokens, ~$%.4f", t.PromptTokens, t.CompletionTokens, t.Cost)
+	if len(t.Unpriced) > 0 {
+		s += fmt.Sprintf(" (cost unknown for %s)", strings.Join(t.Unpriced, ", "))
+	}
+
+	return s
+}
+
+// Tracker keeps the usage of the current turn, one question and its answer,
+// and of the whole conversation
+type Tracker struct {
+	prices       PriceTable
+	turn         Totals
+	conversation Totals
+}
+
+func NewTracker(prices PriceTable) *Tracker {
+	return &Tracker{prices: prices}
+}
+
+// Record adds a model call to the turn and conversation and returns its
+// estimated cost, and whether the model has a price
+func (t *Tracker) Record(model string, usage openai.Usage) (float64, bool) {
+	cost, priced := t.prices.Cost(model, usage)
+
+	t.turn.add(model, usage, cost, priced)
+	t.conversation.add(model, usage, cost, priced)
+
+	return cost, priced
+}
+
+// StartTurn resets the turn totals before a new question
+func (t *Tracker) StartTurn() {
+	t.turn = Totals{}
+}
+
+// Reset forgets the turn and conversation totals
+func (t *Tracker) Reset() {
+	t.turn = Totals{}
+	t.conversation = Totals{}
+}
+
+func (t *Tracker) Turn() Totals {
+	return t.turn
+}
+
+func (t *Tracker) Conversation() Totals {
+	return t.conversation
+}
+
+// Summary is the line shown after each answer
+func (t *Tracker) Summary() string {
+	return fmt.Sprintf("[usage] this answer: %s | conversation: %s", t.turn, t.conversation)
+}
@@ -0,0 +1,95 @@
+package usage
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestCost(t *testing.T) {
+	tokens := openai.Usage{PromptTokens: 1_000_000, CompletionTokens: 100_000}
+
+	tests := []struct {
+		model  string
+		cost   float64
+		priced bool
+	}{
+		{"gpt-4o", 2.50 + 1.00, true},
+		{"gpt-4o-2024-08-06", 2.50 + 1.00, true},
+		{"gpt-4o-mini-2024-07-18", 0.15 + 0.06, true},
+		{"llama3.1", 0, false},
+	}
+
+	for _, tt := range tests {
+		cost, priced := DefaultPrices.Cost(tt.model, tokens)
+		if priced != tt.priced || math.Abs(cost-tt.cost) > 1e-9 {
+			t.Errorf("%s: expected %v (%v), got %v (%v)", tt.model, tt.cost, tt.priced, cost, priced)
+		}
+	}
+}
+
+func TestLoadPrices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	if err := os.WriteFile(path, []byte(`{"llama3.1": {"prompt": 0, "completion": 0}, "gpt-4o": {"prompt": 5, "completion": 15}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadPrices(path)
+	if err != nil {
+		t.Fatalf("LoadPrices returned an error: %v", err)
+	}
+
+	prices := DefaultPrices.Merge(loaded)
+
+	if _, priced := prices.Cost("llama3.1", openai.Usage{}); !priced {
+		t.Error("expected llama3.1 to be priced")
+	}
+
+	if prices["gpt-4o"].Prompt != 5 || prices["gpt-4o-mini"].Prompt != 0.15 {
+		t.Errorf("expected overrides on top of the defaults, got %+v", prices)
+	}
+
+	if DefaultPrices["gpt-4o"].Prompt != 2.50 {
+		t.Error("Merge modified the defaults")
+	}
+}
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker(DefaultPrices)
+
+	tracker.Record("gpt-4o", openai.Usage{PromptTokens: 1000, CompletionTokens: 100})
+	tracker.Record("gpt-4o-mini", openai.Usage{PromptTokens: 2000, CompletionTokens: 200})
+
+	tracker.StartTurn()
+	tracker.Record("llama3.1", openai.Usage{PromptTokens: 500, CompletionTokens: 50})
+
+	turn := tracker.Turn()
+	if turn.Calls != 1 || turn.PromptTokens != 500 || turn.Cost != 0 || len(turn.Unpriced) != 1 {
+		t.Errorf("unexpected turn totals %+v", turn)
+	}
+
+	conversation := tracker.Conversation()
+	if conversation.Calls != 3 || conversation.PromptTokens != 3500 || conversation.CompletionTokens != 350 {
+		t.Errorf("unexpected conversation totals %+v", conversation)
+	}
+
+	if math.Abs(conversation.Cost-0.00392) > 1e-9 {
+		t.Errorf("expected a conversation cost of 0.00392, got %v", conversation.Cost)
+	}
+
+	summary := tracker.Summary()
+	for _, want := range []string{"this answer: 500 prompt + 50 completion tokens", "~$0.0039", "cost unknown for llama3.1"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary %q is missing %q", summary, want)
+		}
+	}
+
+	tracker.Reset()
+	if tracker.Conversation().Calls != 0 {
+		t.Error("expected Reset to clear the conversation totals")
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/kmesiab/chime-ai/ai/agent"
+	"github.com/kmesiab/chime-ai/ai/provider"
+	"github.com/kmesiab/chime-ai/ai/tools/transactions"
+	"github.com/kmesiab/chime-ai/ai/usage"
+	"github.com/kmesiab/chime-ai/config"
+	"github.com/kmesiab/chime-ai/database"
+)
+
+var memory []openai.ChatCompletionMessage
+
+// chatCommand answers questions read from stdin until EOF or /quit
+func chatCommand(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("chime-ai chat", flag.ExitOnError)
+	pricesPath := assistantFlags(cfg, fs)
+	resume := fs.Uint("resume", 0, "ID of a saved conversation to continue, see /sessions")
+	_ = fs.Parse(args)
+
+	assistant, closeDBs, err := newAssistant(cfg, *pricesPath)
+	if err != nil {
+		return err
+	}
+	defer closeDBs()
+
+	if *resume != 0 {
+		if err := resumeConversation(*resume); err != nil {
+			return fmt.Errorf("failed to resume conversation: %w", err)
+		}
+
+		fmt.Printf("Resumed conversation %d: %s\n\n", conversation.ID, conversation.Title)
+		writeHistory(os.Stdout)
+	}
+
+	ask := func(ctx context.Context, question string) (string, error) {
+		tracker.StartTurn()
+
+		answer, err := askQuestion(ctx, question, assistant, cfg.AI.Timeout)
+		if err != nil {
+			return "", err
+		}
+
+		return answer + "\n\n" + tracker.Summary(), nil
+	}
+
+	if err := runChat(context.Background(), os.Stdin, os.Stdout, ask); err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return nil
+}
+
+// askCommand answers the question given as arguments and exits. The
+// question is saved as a new conversation that chat can resume.
+func askCommand(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("chime-ai ask", flag.ExitOnError)
+	pricesPath := assistantFlags(cfg, fs)
+	_ = fs.Parse(args)
+
+	question := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if question == "" {
+		return errors.New(`usage: chime-ai ask [flags] "<question>"`)
+	}
+
+	assistant, closeDBs, err := newAssistant(cfg, *pricesPath)
+	if err != nil {
+		return err
+	}
+	defer closeDBs()
+
+	answer, err := askQuestion(context.Background(), question, assistant, cfg.AI.Timeout)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n\n%s\n", answer, tracker.Summary())
+
+	return nil
+}
+
+// assistantFlags registers the flags shared by chat and ask and returns the
+// path of the price table
+func assistantFlags(cfg *config.Config, fs *flag.FlagSet) *string {
+	cfg.AIFlags(fs)
+
+	return fs.String("prices", "", "JSON file of model prices in dollars per million tokens, added to the built-in prices")
+}
+
+// newAssistant connects to the database and the model and sets up the agent,
+// the usage tracker and memory. The returned function closes the database
+// connections.
+func newAssistant(cfg *config.Config, pricesPath string) (*agent.Agent, func(), error) {
+
+	// Local servers don't need a key, the OpenAI API does
+	if cfg.AI.APIKey == "" && cfg.AI.BaseURL == "" {
+		return nil, nil, errors.New("OPENAI_API_KEY environment variable not set")
+	}
+
+	prices := usage.DefaultPrices.Merge(cfg.AI.Prices)
+	if pricesPath != "" {
+		filePrices, err := usage.LoadPrices(pricesPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load prices: %w", err)
+		}
+		prices = prices.Merge(filePrices)
+	}
+
+	var err error
+
+	// Usage and history are written through a read-write connection, which
+	// also brings the schema up to date
+	if appDB, err = database.Open(cfg.Database); err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err = database.Migrate(appDB); err != nil {
+		closeDB(appDB)
+		return nil, nil, err
+	}
+
+	// Transactions imported before merchants and categories existed get
+	// theirs here
+	if _, err = database.AssignMerchants(appDB, true); err != nil {
+		log.Printf("Error assigning merchants: %v\n", err)
+	}
+
+	if _, err = database.AssignCategories(appDB, true); err != nil {
+		log.Printf("Error categorizing transactions: %v\n", err)
+	}
+
+	// The model's queries only ever see a read-only connection
+	db, err := database.OpenReadOnly(cfg.Database)
+	if err != nil {
+		closeDB(appDB)
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	closeDBs := func() {
+		closeDB(db)
+		closeDB(appDB)
+	}
+
+	describeOptions := transactions.DefaultDescribeOptions
+	describeOptions.SampleRows = cfg.AI.SampleRows
+	describeOptions.Redact = cfg.AI.Redact
+
+	description, err := transactions.Describe(db, describeOptions)
+	if err != nil {
+		closeDBs()
+		return nil, nil, fmt.Errorf("failed to describe the database: %w", err)
+	}
+
+	tracker = usage.NewTracker(prices)
+
+	sandbox := database.NewSandbox(db)
+	sandbox.Timeout = cfg.AI.QueryTimeout
+	sandbox.MaxRows = cfg.AI.MaxRows
+
+	tool := transactions.NewHandler(sandbox, description)
+	tool.ResultRows = cfg.AI.ResultRows
+	tool.OnQuery = func(sql string) {
+		fmt.Printf("Executing SQL query: %s\n", sql)
+	}
+
+	assistant := agent.New(provider.NewOpenAI(cfg.AI.APIKey, cfg.AI.BaseURL), tool)
+	assistant.MaxIterations = cfg.AI.MaxIterations
+	assistant.MaxRetries = cfg.AI.MaxRetries
+	assistant.Model = cfg.AI.Model
+	assistant.FollowUpModel = cfg.AI.FollowUpModel
+	assistant.Temperature = cfg.AI.Temperature
+	assistant.FrequencyPenalty = cfg.AI.FrequencyPenalty
+	assistant.OnUsage = recordUsage
+
+	memory = []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: cfg.AI.SystemPrompt,
+		},
+	}
+
+	return assistant, closeDBs, nil
+}
+
+// askQuestion adds the question to memory and lets the agent answer it,
+// querying the database as often as the model needs to. The tool calls, their
+// results and the answer are kept in memory for follow-up questions. If
+// anything fails the whole turn is dropped so the next question starts from a
+// clean state.
+func askQuestion(ctx context.Context, question string, assistant *agent.Agent, timeout time.Duration) (string, error) {
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	messages := append(memory, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: question,
+	})
+
+	answer, messages, err := assistant.Run(timeoutCtx, messages)
+	if err != nil {
+		return "", err
+	}
+
+	memory = messages
+
+	if err := saveConversation(); err != nil {
+		log.Printf("Error saving conversation: %v\n", err)
+	}
+
+	return answer, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kmesiab/chime-ai/config"
+	"github.com/kmesiab/chime-ai/database"
+)
+
+const categoriesUsage = `usage: chime-ai categories <action> [flags]
+
+Actions:
+  list                     list categories by money spent
+  rules                    list the rules, in the order they are tried
+  add-rule -category <name> [-pattern <re>] [-type <type>] [-min <amount>] [-max <amount>] [-priority <n>]
+                           put transactions matching every given condition in a category
+  remove-rule <id>         delete a rule
+  test [-type <type>] [-amount <amount>] "<description>"
+                           show the category a transaction would get
+  apply                    recategorize every transaction`
+
+// categoriesCommand lists categories and edits the rules that put
+// transactions in them. Changing a rule recategorizes every transaction.
+func categoriesCommand(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(categoriesUsage)
+	}
+
+	action := args[0]
+	switch action {
+	case "list", "rules", "add-rule", "remove-rule", "test", "apply":
+	default:
+		return fmt.Errorf("unknown action %q\n\n%s", action, categoriesUsage)
+	}
+
+	fs := flag.NewFlagSet("chime-ai categories "+action, flag.ExitOnError)
+	cfg.DatabaseFlags(fs)
+
+	var (
+		rule                 database.CategoryRule
+		minAmount, maxAmount string
+		transaction          database.Transaction
+		amount               string
+	)
+
+	switch action {
+	case "add-rule":
+		fs.StringVar(&rule.Category, "category", "", "Category the matching transactions belong to")
+		fs.StringVar(&rule.Pattern, "pattern", "", "Case-insensitive regular expression matched against descriptions")
+		fs.StringVar(&rule.Type, "type", "", "Transaction type, e.g. Purchase")
+		fs.StringVar(&minAmount, "min", "", "Smallest amount matched, ignoring its sign, e.g. 100.00")
+		fs.StringVar(&maxAmount, "max", "", "Largest amount matched, ignoring its sign")
+		fs.IntVar(&rule.Priority, "priority", 0, "Rules with a higher priority are tried first")
+	case "test":
+		fs.StringVar(&transaction.Type, "type", "Purchase", "Transaction type")
+		fs.StringVar(&amount, "amount", "0", "Transaction amount, e.g. -12.50")
+	}
+	_ = fs.Parse(args[1:])
+
+	var err error
+	if rule.MinAmount, err = optionalMoney(minAmount); err != nil {
+		return err
+	}
+	if rule.MaxAmount, err = optionalMoney(maxAmount); err != nil {
+		return err
+	}
+
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer closeDB(db)
+
+	if err := database.Migrate(db); err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		return writeCategories(db)
+
+	case "rules":
+		return writeCategoryRules(db)
+
+	case "add-rule":
+		if err := database.AddCategoryRule(db, &rule); err != nil {
+			return err
+		}
+		fmt.Printf("Added rule %d.\n", rule.ID)
+
+	case "remove-rule":
+		id, err := strconv.ParseUint(fs.Arg(0), 10, 0)
+		if err != nil {
+			return fmt.Errorf("expected a rule ID from chime-ai categories rules, got %q", fs.Arg(0))
+		}
+		if err := database.DeleteCategoryRule(db, uint(id)); err != nil {
+			return err
+		}
+		fmt.Printf("Removed rule %d.\n", id)
+
+	case "test":
+		if transaction.Amount, err = database.ParseMoney(amount); err != nil {
+			return err
+		}
+
+		categorizer, err := database.NewCategorizer(db)
+		if err != nil {
+			return err
+		}
+
+		transaction.Description = strings.Join(fs.Args(), " ")
+
+		category := categorizer.Categorize(transaction)
+		if category == "" {
+			category = "no category"
+		}
+		fmt.Printf("%s -> %s\n", transaction.Description, category)
+		return nil
+	}
+
+	// The rules changed, or recategorizing was asked for
+	changed, err := database.AssignCategories(db, false)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Recategorized %d transactions.\n", changed)
+
+	return nil
+}
+
+// optionalMoney parses an amount flag, which is nil when not given
+func optionalMoney(s string) (*database.Money, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	amount, err := database.ParseMoney(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &amount, nil
+}
+
+func writeCategories(db *gorm.DB) error {
+	categories, err := database.NewTransactionRepository(db).SpendingByCategory(time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	for _, category := range categories {
+		name := category.Category
+		if name == "" {
+			name = "(no category)"
+		}
+
+		fmt.Printf("%6d  %12s  %s\n", category.Transactions, category.Spent.Format(database.DefaultCurrency), name)
+	}
+
+	return nil
+}
+
+func writeCategoryRules(db *gorm.DB) error {
+	rules, err := database.ListCategoryRules(db)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		fmt.Printf("%4d  priority %-3d  %-16s  %s\n", rule.ID, rule.Priority, rule.Category, rule)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const chatHelp = `Ask a question about your transactions, or use one of these commands:
+  /history        show the conversation so far
+  /reset          start a new conversation, the current one stays saved
+  /sessions       list saved conversations
+  /resume <id>    continue a saved conversation
+  /delete <id>    delete a saved conversation
+  /save [file]    save the conversation as JSON
+  /usage          show tokens and cost of this conversation and each month
+  /help           show this help
+  /quit           exit
+`
+
+// askFunc answers a single question, adding it and the answer to memory
+type askFunc func(ctx context.Context, question string) (string, error)
+
+// runChat reads questions from in until EOF or /quit, answering each one with
+// ask. The conversation is kept in memory so follow-up questions have the
+// context of the earlier ones.
+func runChat(ctx context.Context, in io.Reader, out io.Writer, ask askFunc) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	fmt.Fprint(out, chatHelp)
+
+	for {
+		fmt.Fprint(out, "\n> ")
+
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "/") {
+			if quit := runChatCommand(out, line); quit {
+				return nil
+			}
+			continue
+		}
+
+		answer, err := ask(ctx, line)
+		if err != nil {
+			fmt.Fprintf(out, "An error occurred while processing the analysis:\n%v\n", err)
+			continue
+		}
+
+		fmt.Fprintln(out, answer)
+	}
+}
+
+// runChatCommand handles a slash command and reports whether the chat
+// should end
+func runChatCommand(out io.Writer, line string) bool {
+	command, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch command {
+	case "/quit", "/exit":
+		return true
+
+	case "/help":
+		fmt.Fprint(out, chatHelp)
+
+	case "/reset":
+		newConversation()
+		fmt.Fprintln(out, "Conversation reset.")
+
+	case "/sessions":
+		writeConversations(out)
+
+	case "/resume", "/delete":
+		id, err := parseConversationID(arg)
+		if err == nil && command == "/resume" {
+			err = resumeConversation(id)
+		} else if err == nil {
+			err = deleteConversation(id)
+		}
+
+		switch {
+		case err != nil:
+			fmt.Fprintf(out, "Error: %v\n", err)
+		case command == "/resume":
+			fmt.Fprintf(out, "Resumed conversation %d: %s\n", id, conversation.Title)
+			writeHistory(out)
+		default:
+			fmt.Fprintf(out, "Conversation %d deleted.\n", id)
+		}
+
+	case "/usage":
+		writeUsage(out)
+
+	case "/history":
+		writeHistory(out)
+
+	case "/save":
+		if arg == "" {
+			arg = fmt.Sprintf("chat-%s.json", time.Now().Format("20060102-150405"))
+		}
+
+		if err := saveMemory(arg); err != nil {
+			fmt.Fprintf(out, "Error saving conversation: %v\n", err)
+		} else {
+			fmt.Fprintf(out, "Conversation saved to %s\n", arg)
+		}
+
+	default:
+		fmt.Fprintf(out, "Unknown command %s, type /help for a list of commands\n", command)
+	}
+
+	return false
+}
+
+// resetMemory drops everything but the system prompt
+func resetMemory() {
+	if len(memory) > 0 && memory[0].Role == openai.ChatMessageRoleSystem {
+		memory = memory[:1]
+	} else {
+		memory = nil
+	}
+}
+
+// writeHistory prints the questions and answers in the conversation, leaving
+// out the system prompt and tool calls
+func writeHistory(out io.Writer) {
+	var shown int
+
+	for _, message := range memory {
+		if message.Role == openai.ChatMessageRoleSystem || message.Role == openai.ChatMessageRoleTool || message.Content == "" {
+			continue
+		}
+
+		fmt.Fprintf(out, "[%s] %s\n\n", message.Role, strings.TrimSpace(message.Content))
+		shown++
+	}
+
+	if shown == 0 {
+		fmt.Fprintln(out, "No messages yet.")
+	}
+}
+
+// saveMemory writes the conversation, system prompt included, to path
+func saveMemory(path string) error {
+	data, err := json.MarshalIndent(memory, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/kmesiab/chime-ai/config"
+)
+
+// echoAsk answers every question with a canned reply and records the turn in
+// memory the way askQuestion does
+func echoAsk(_ context.Context, question string) (string, error) {
+	answer := "answer to " + question
+	memory = append(memory,
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: question},
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: answer},
+	)
+
+	return answer, nil
+}
+
+func TestRunChat(t *testing.T) {
+	memory = []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleSystem, Content: config.DefaultSystemPrompt}}
+	savePath := filepath.Join(t.TempDir(), "chat.json")
+
+	input := strings.Join([]string{
+		"How much did I spend?",
+		"And last month?",
+		"/history",
+		"/save " + savePath,
+		"/reset",
+		"/history",
+		"/bogus",
+		"/quit",
+		"never asked",
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := runChat(context.Background(), strings.NewReader(input), &out, echoAsk); err != nil {
+		t.Fatalf("runChat returned an error: %v", err)
+	}
+
+	output := out.String()
+	for _, want := range []string{
+		"answer to How much did I spend?",
+		"[user] And last month?",
+		"[assistant] answer to And last month?",
+		"Conversation saved to " + savePath,
+		"Conversation reset.",
+		"No messages yet.",
+		"Unknown command /bogus",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output is missing %q:\n%s", want, output)
+		}
+	}
+
+	if strings.Contains(output, "never asked") {
+		t.Errorf("input after /quit was processed:\n%s", output)
+	}
+
+	if len(memory) != 1 || memory[0].Role != openai.ChatMessageRoleSystem {
+		t.Errorf("expected only the system prompt after /reset, got %d messages", len(memory))
+	}
+
+	data, err := os.ReadFile(savePath)
+	if err != nil {
+		t.Fatalf("failed to read saved conversation: %v", err)
+	}
+
+	var saved []openai.ChatCompletionMessage
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("saved conversation is not valid JSON: %v", err)
+	}
+
+	if len(saved) != 5 {
+		t.Errorf("expected 5 saved messages, got %d", len(saved))
+	}
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kmesiab/chime-ai/ai/agent"
+	"github.com/kmesiab/chime-ai/ai/tools/transactions"
+	"github.com/kmesiab/chime-ai/ai/usage"
+	"github.com/kmesiab/chime-ai/database"
+)
+
+// FileName is the config file looked for in the working directory and in
+// the user's config directory under chime-ai/
+const FileName = "chime-ai.yaml"
+
+// DefaultSystemPrompt is the assistant's system prompt unless the config
+// replaces it
+const DefaultSystemPrompt = `You are a financial advisor and a SQL expert with access to a transaction
+history database via tools and can query it for more robust data and analysis. You use database results to make
+informed responses to help the user.`
+
+// Config holds the settings of the chat app and the importer. Each setting
+// comes from, in increasing priority, the defaults, the config file,
+// environment variables and command line flags.
+type Config struct {
+	// Database is the path of the SQLite database
+	Database string `yaml:"database"`
+
+	AI     AI     `yaml:"ai"`
+	Import Import `yaml:"import"`
+
+	// Path is the config file that was read, if any
+	Path string `yaml:"-"`
+}
+
+// AI configures the model and how it queries the database
+type AI struct {
+	APIKey           string           `yaml:"api_key"`
+	BaseURL          string           `yaml:"base_url"`
+	Model            string           `yaml:"model"`
+	FollowUpModel    string           `yaml:"follow_up_model"`
+	Temperature      float32          `yaml:"temperature"`
+	FrequencyPenalty float32          `yaml:"frequency_penalty"`
+	Timeout          time.Duration    `yaml:"timeout"`
+	SystemPrompt     string           `yaml:"system_prompt"`
+	MaxIterations    int              `yaml:"max_iterations"`
+	MaxRetries       int              `yaml:"max_retries"`
+	QueryTimeout     time.Duration    `yaml:"query_timeout"`
+	MaxRows          int              `yaml:"max_rows"`
+	ResultRows       int              `yaml:"result_rows"`
+	SampleRows       int              `yaml:"sample_rows"`
+	Redact           []string         `yaml:"redact"`
+	Prices           usage.PriceTable `yaml:"prices"`
+}
+
+// Import configures the importer
+type Import struct {
+	Dir         string `yaml:"dir"`
+	Parser      string `yaml:"parser"`
+	PDFBackend  string `yaml:"pdf_backend"`
+	CSVProfiles string `yaml:"csv_profiles"`
+	Account     string `yaml:"account"`
+}
+
+// Default returns the built-in settings
+func Default() *Config {
+	return &Config{
+		Database: database.DefaultPath,
+		AI: AI{
+			Model:            openai.GPT4o,
+			FollowUpModel:    openai.GPT4oMini,
+			Temperature:      0.7,
+			FrequencyPenalty: 0.7,
+			Timeout:          30 * time.Second,
+			SystemPrompt:     DefaultSystemPrompt,
+			MaxIterations:    agent.DefaultMaxIterations,
+			MaxRetries:       agent.DefaultMaxRetries,
+			QueryTimeout:     database.DefaultQueryTimeout,
+			MaxRows:          database.DefaultMaxRows,
+			ResultRows:       transactions.DefaultResultRows,
+			SampleRows:       transactions.DefaultDescribeOptions.SampleRows,
+			Redact:           append([]string(nil), transactions.DefaultDescribeOptions.Redact...),
+		},
+		Import: Import{
+			Dir:        "./importer/files",
+			PDFBackend: "builtin",
+		},
+	}
+}
+
+// Load reads the defaults, then the config file, then the environment. The
+// file is the one named by a -config flag in args, by CHIME_AI_CONFIG, or
+// else the first FileName found in the working directory or the user's
+// config directory. Flags are applied afterwards by parsing a flag set set
+// up with AIFlags or ImportFlags.
+func Load(args []string) (*Config, error) {
+	config := Default()
+
+	path, explicit := configPath(args)
+	if path != "" {
+		if err := config.readFile(path); err != nil {
+			if explicit || !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+		} else {
+			config.Path = path
+		}
+	}
+
+	if err := config.readEnv(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// configPath finds the config file and reports whether the user named it
+func configPath(args []string) (string, bool) {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+
+		if hasValue {
+			return value, true
+		}
+		if i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+
+	if path := os.Getenv("CHIME_AI_CONFIG"); path != "" {
+		return path, true
+	}
+
+	if _, err := os.Stat(FileName); err == nil {
+		return FileName, false
+	}
+
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "chime-ai", FileName), false
+	}
+
+	return "", false
+}
+
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	// An empty file decodes to io.EOF
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// readEnv applies the environment variables that are set
+func (c *Config) readEnv() error {
+	stringVars := map[string]*string{
+		"CHIME_AI_DATABASE":        &c.Database,
+		"OPENAI_API_KEY":           &c.AI.APIKey,
+		"OPENAI_BASE_URL":          &c.AI.BaseURL,
+		"CHIME_AI_MODEL":           &c.AI.Model,
+		"CHIME_AI_FOLLOW_UP_MODEL": &c.AI.FollowUpModel,
+		"CHIME_AI_SYSTEM_PROMPT":   &c.AI.SystemPrompt,
+	}
+
+	for name, field := range stringVars {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			*field = value
+		}
+	}
+
+	floatVars := map[string]*float32{
+		"CHIME_AI_TEMPERATURE":       &c.AI.Temperature,
+		"CHIME_AI_FREQUENCY_PENALTY": &c.AI.FrequencyPenalty,
+	}
+
+	for name, field := range floatVars {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			if err := (*float32Value)(field).Set(value); err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+		}
+	}
+
+	if value, ok := os.LookupEnv("CHIME_AI_TIMEOUT"); ok && value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid CHIME_AI_TIMEOUT: %w", err)
+		}
+		c.AI.Timeout = timeout
+	}
+
+	return nil
+}
+
+// commonFlags registers the flags shared by every command
+func (c *Config) commonFlags(fs *flag.FlagSet) {
+	// Load has already read the file, the flag is only registered so it
+	// parses and shows up in the usage
+	fs.String("config", c.Path, "YAML config file (default ./"+FileName+" or the user config directory)")
+	fs.StringVar(&c.Database, "db", c.Database, "Path of the SQLite database")
+}
+
+// DatabaseFlags registers the flags of the commands that only read or
+// update the database
+func (c *Config) DatabaseFlags(fs *flag.FlagSet) {
+	c.commonFlags(fs)
+}
+
+// AIFlags registers the chat app's flags, defaulting to the loaded settings
+func (c *Config) AIFlags(fs *flag.FlagSet) {
+	c.commonFlags(fs)
+
+	fs.StringVar(&c.AI.BaseURL, "base-url", c.AI.BaseURL, "Base URL of an OpenAI-compatible server, such as http://localhost:11434/v1 for Ollama")
+	fs.StringVar(&c.AI.Model, "model", c.AI.Model, "Model for the first call of each question")
+	fs.StringVar(&c.AI.FollowUpModel, "follow-up-model", c.AI.FollowUpModel, "Model for the calls after a query ran")
+	fs.Var((*float32Value)(&c.AI.Temperature), "temperature", "Sampling temperature of the follow-up calls")
+	fs.Var((*float32Value)(&c.AI.FrequencyPenalty), "frequency-penalty", "Frequency penalty of the follow-up calls")
+	fs.DurationVar(&c.AI.Timeout, "timeout", c.AI.Timeout, "Maximum time to answer a question")
+	fs.StringVar(&c.AI.SystemPrompt, "system-prompt", c.AI.SystemPrompt, "System prompt of the assistant")
+	fs.IntVar(&c.AI.MaxIterations, "max-iterations", c.AI.MaxIterations, "Maximum number of model calls per question")
+	fs.IntVar(&c.AI.MaxRetries, "max-retries", c.AI.MaxRetries, "Maximum number of failed queries the model may correct per question")
+	fs.DurationVar(&c.AI.QueryTimeout, "query-timeout", c.AI.QueryTimeout, "Maximum time a single query may run")
+	fs.IntVar(&c.AI.MaxRows, "max-rows", c.AI.MaxRows, "Maximum number of rows a single query returns")
+	fs.IntVar(&c.AI.ResultRows, "result-rows", c.AI.ResultRows, "Maximum number of rows of a query result sent to the model")
+	fs.IntVar(&c.AI.SampleRows, "sample-rows", c.AI.SampleRows, "Number of sample rows shown to the model, 0 for none")
+	fs.Var((*listValue)(&c.AI.Redact), "redact", "Comma separated columns whose sample values are hidden from the model")
+}
+
+// ImportFlags registers the importer's settings flags, defaulting to the
+// loaded settings
+func (c *Config) ImportFlags(fs *flag.FlagSet) {
+	c.commonFlags(fs)
+
+	fs.StringVar(&c.Import.Dir, "dir", c.Import.Dir, "Directory containing PDFs, text, CSV and OFX/QFX files")
+	fs.StringVar(&c.Import.Parser, "parser", c.Import.Parser, "Statement parser to use instead of detecting it")
+	fs.StringVar(&c.Import.PDFBackend, "pdf-backend", c.Import.PDFBackend, "PDF text extraction backend (builtin or pdftotext)")
+	fs.StringVar(&c.Import.CSVProfiles, "csv-profiles", c.Import.CSVProfiles, "JSON file with additional CSV column mapping profiles")
+	fs.StringVar(&c.Import.Account, "account", c.Import.Account, "Account the statements belong to, instead of detecting it (e.g. \"Chime Savings\")")
+}
+
+// float32Value is a flag.Value for float32 settings
+type float32Value float32
+
+func (f *float32Value) String() string {
+	if f == nil {
+		return "0"
+	}
+
+	return strconv.FormatFloat(float64(*f), 'g', -1, 32)
+}
+
+func (f *float32Value) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return err
+	}
+
+	*f = float32Value(v)
+
+	return nil
+}
+
+// listValue is a flag.Value for comma separated lists
+type listValue []string
+
+func (l *listValue) String() string {
+	if l == nil {
+		return ""
+	}
+
+	return strings.Join(*l, ",")
+}
+
+func (l *listValue) Set(s string) error {
+	*l = nil
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isolate points the user config directory at an empty temp dir and clears
+// the environment variables Load reads
+func isolate(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	for _, name := range []string{
+		"CHIME_AI_CONFIG", "CHIME_AI_DATABASE", "OPENAI_API_KEY", "OPENAI_BASE_URL",
+		"CHIME_AI_MODEL", "CHIME_AI_FOLLOW_UP_MODEL", "CHIME_AI_SYSTEM_PROMPT",
+		"CHIME_AI_TEMPERATURE", "CHIME_AI_FREQUENCY_PENALTY", "CHIME_AI_TIMEOUT",
+	} {
+		t.Setenv(name, "")
+	}
+
+	return dir
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	isolate(t)
+
+	config, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+
+	if config.Path != "" {
+		t.Errorf("expected no config file, got %s", config.Path)
+	}
+
+	want := Default()
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("expected the defaults\n got %+v\nwant %+v", config, want)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := isolate(t)
+
+	path := filepath.Join(dir, "team.yaml")
+	writeConfig(t, path, `
+database: /data/alice.db
+ai:
+  model: llama3.1
+  follow_up_model: llama3.1
+  temperature: 0.2
+  timeout: 2m
+  system_prompt: Be brief.
+  redact: [description, account]
+  prices:
+    llama3.1: {prompt: 0, completion: 0}
+import:
+  dir: ~/statements
+`)
+
+	t.Setenv("CHIME_AI_MODEL", "qwen2.5")
+	t.Setenv("CHIME_AI_TIMEOUT", "90s")
+	t.Setenv("OPENAI_BASE_URL", "http://localhost:11434/v1")
+
+	args := []string{"-config", path, "-model", "mistral", "-redact", "description", "-frequency-penalty", "0"}
+
+	config, err := Load(args)
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	config.AIFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"path", config.Path, path},
+		{"database from file", config.Database, "/data/alice.db"},
+		{"model from flag over env and file", config.AI.Model, "mistral"},
+		{"follow-up model from file", config.AI.FollowUpModel, "llama3.1"},
+		{"timeout from env over file", config.AI.Timeout, 90 * time.Second},
+		{"base URL from env", config.AI.BaseURL, "http://localhost:11434/v1"},
+		{"temperature from file", config.AI.Temperature, float32(0.2)},
+		{"frequency penalty from flag", config.AI.FrequencyPenalty, float32(0)},
+		{"system prompt from file", config.AI.SystemPrompt, "Be brief."},
+		{"redact from flag", config.AI.Redact, []string{"description"}},
+		{"import dir from file", config.Import.Dir, "~/statements"},
+		{"default kept", config.AI.MaxIterations, Default().AI.MaxIterations},
+	}
+
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	if _, ok := config.AI.Prices["llama3.1"]; !ok {
+		t.Errorf("expected prices from the file, got %v", config.AI.Prices)
+	}
+}
+
+func TestLoadUserConfigDir(t *testing.T) {
+	dir := isolate(t)
+
+	userConfig, err := os.UserConfigDir()
+	if err != nil {
+		t.Skipf("no user config directory: %v", err)
+	}
+
+	path := filepath.Join(userConfig, "chime-ai", FileName)
+	writeConfig(t, path, "database: "+filepath.Join(dir, "mine.db")+"\n")
+
+	config, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+
+	if config.Path != path || config.Database != filepath.Join(dir, "mine.db") {
+		t.Errorf("expected the user config to be read, got %+v", config)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := isolate(t)
+
+	if _, err := Load([]string{"-config=" + filepath.Join(dir, "missing.yaml")}); err == nil {
+		t.Error("expected an error for a missing config file named on the command line")
+	}
+
+	unknown := filepath.Join(dir, "unknown.yaml")
+	writeConfig(t, unknown, "ai:\n  modle: gpt-4o\n")
+	if _, err := Load([]string{"--config", unknown}); err == nil {
+		t.Error("expected an error for an unknown setting")
+	}
+
+	t.Setenv("CHIME_AI_TEMPERATURE", "warm")
+	if _, err := Load(nil); err == nil {
+		t.Error("expected an error for an invalid CHIME_AI_TEMPERATURE")
+	}
+}
+
+func TestExampleConfig(t *testing.T) {
+	isolate(t)
+
+	config, err := Load([]string{"-config", "../chime-ai.example.yaml"})
+	if err != nil {
+		t.Fatalf("failed to load the example config: %v", err)
+	}
+
+	// The example lists the defaults, apart from a sample price
+	config.Path = ""
+	config.AI.Prices = nil
+	config.AI.SystemPrompt = strings.TrimSpace(config.AI.SystemPrompt)
+
+	if want := Default(); !reflect.DeepEqual(config, want) {
+		t.Errorf("the example config doesn't match the defaults\n got %+v\nwant %+v", config, want)
+	}
+}
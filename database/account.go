@@ -0,0 +1,106 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Account kinds
+const (
+	AccountChecking = "checking"
+	AccountSavings  = "savings"
+	AccountCredit   = "credit"
+	AccountOther    = "other"
+)
+
+// transferTypes are the transaction types that move money between a
+// user's own accounts
+var transferTypes = []string{"Transfer", "Round Up"}
+
+// transferWindowDays is how far apart the two sides of a transfer can be
+// dated, since the receiving account often posts it a day or two later
+const transferWindowDays = 3
+
+// Account is a bank account that statements are imported from
+type Account struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"uniqueIndex"` // e.g. "Chime Checking"
+	Institution string
+	Kind        string
+	Number      string // Last four digits of the account number, when known
+	CreatedAt   time.Time
+}
+
+// FindOrCreateAccount looks up an account by name, creating it from the
+// given details if it doesn't exist yet. account is updated in place with
+// the stored record.
+func FindOrCreateAccount(db *gorm.DB, account *Account) error {
+	if account.Name == "" {
+		return fmt.Errorf("account name is required")
+	}
+
+	if err := db.Where(Account{Name: account.Name}).FirstOrCreate(account).Error; err != nil {
+		return fmt.Errorf("failed to find or create account %q: %w", account.Name, err)
+	}
+
+	return nil
+}
+
+// PairTransfers links the two sides of transfers between a user's own
+// accounts, so money moved from checking to savings isn't counted as
+// spending. A transfer out of one account is paired with an unpaired
+// transfer into a different account for the same amount, dated within a
+// few days; the closest date wins. It returns the number of pairs made.
+func PairTransfers(db *gorm.DB) (int, error) {
+	type candidate struct {
+		OutID uint
+		InID  uint
+	}
+
+	var candidates []candidate
+	err := db.Raw(`
+		SELECT o.id AS out_id, i.id AS in_id
+		FROM transactions o
+		JOIN transactions i
+			ON i.account_id <> o.account_id
+			AND i.net_amount_cents = -o.net_amount_cents
+			AND ABS(julianday(i.date) - julianday(o.date)) <= ?
+		WHERE o.net_amount_cents < 0
+			AND o.transfer_id IS NULL AND i.transfer_id IS NULL
+			AND o.type IN ? AND i.type IN ?
+		ORDER BY o.date, o.id, ABS(julianday(i.date) - julianday(o.date)), i.id`,
+		transferWindowDays, transferTypes, transferTypes).
+		Scan(&candidates).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to find transfer candidates: %w", err)
+	}
+
+	paired := 0
+	used := map[uint]bool{}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, c := range candidates {
+			if used[c.OutID] || used[c.InID] {
+				continue
+			}
+			used[c.OutID], used[c.InID] = true, true
+
+			if err := tx.Model(&Transaction{}).Where("id = ?", c.OutID).Update("transfer_id", c.InID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&Transaction{}).Where("id = ?", c.InID).Update("transfer_id", c.OutID).Error; err != nil {
+				return err
+			}
+			paired++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to pair transfers: %w", err)
+	}
+
+	return paired, nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestFindOrCreateAccount(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Account{}); err != nil {
+		t.Fatalf("failed to migrate database schema: %v", err)
+	}
+
+	first := &Account{Name: "Chime Savings", Institution: "Chime", Kind: AccountSavings}
+	if err := FindOrCreateAccount(db, first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second := &Account{Name: "Chime Savings"}
+	if err := FindOrCreateAccount(db, second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if second.ID != first.ID || second.Kind != AccountSavings {
+		t.Errorf("expected the existing account to be returned, got %+v", second)
+	}
+
+	if err := FindOrCreateAccount(db, &Account{}); err == nil {
+		t.Errorf("expected an error for an account without a name")
+	}
+}
+
+func TestPairTransfers(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Account{}, &Transaction{}); err != nil {
+		t.Fatalf("failed to migrate database schema: %v", err)
+	}
+
+	checking := &Account{Name: "Chime Checking", Kind: AccountChecking}
+	savings := &Account{Name: "Chime Savings", Kind: AccountSavings}
+	for _, a := range []*Account{checking, savings} {
+		if err := FindOrCreateAccount(db, a); err != nil {
+			t.Fatalf("failed to create account: %v", err)
+		}
+	}
+
+	day := func(d int) time.Time { return time.Date(2024, 7, d, 0, 0, 0, 0, time.UTC) }
+
+	transactions := []Transaction{
+		{Date: day(1), Description: "Transfer to Chime Savings Account", Type: "Transfer", NetAmount: -10000, AccountID: &checking.ID},
+		{Date: day(2), Description: "Transfer from Chime Checking Account", Type: "Transfer", NetAmount: 10000, AccountID: &savings.ID},
+		// Same amount, but more than a few days later, so not a match
+		{Date: day(20), Description: "Transfer from Chime Checking Account", Type: "Transfer", NetAmount: 10000, AccountID: &savings.ID},
+		// A purchase is never a transfer
+		{Date: day(1), Description: "Grocery Store", Type: "Purchase", NetAmount: -2500, AccountID: &checking.ID},
+		{Date: day(1), Description: "Refund", Type: "Deposit", NetAmount: 2500, AccountID: &savings.ID},
+	}
+	if err := db.Create(&transactions).Error; err != nil {
+		t.Fatalf("failed to seed database: %v", err)
+	}
+
+	paired, err := PairTransfers(db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if paired != 1 {
+		t.Errorf("expected 1 pair, got %d", paired)
+	}
+
+	var out, in Transaction
+	db.First(&out, transactions[0].ID)
+	db.First(&in, transactions[1].ID)
+
+	if out.TransferID == nil || *out.TransferID != in.ID || in.TransferID == nil || *in.TransferID != out.ID {
+		t.Errorf("expected transactions %d and %d to be paired, got %v and %v", out.ID, in.ID, out.TransferID, in.TransferID)
+	}
+
+	// Pairing again finds nothing new
+	if paired, err := PairTransfers(db); err != nil || paired != 0 {
+		t.Errorf("expected no new pairs, got %d, %v", paired, err)
+	}
+}
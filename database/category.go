@@ -0,0 +1,257 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Category is what money was spent on or came from, such as "Dining" or
+// "Income"
+type Category struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"uniqueIndex"`
+	CreatedAt time.Time
+}
+
+// CategoryRule puts the transactions it matches in a category. A rule
+// matches when the description matches Pattern, a case-insensitive regular
+// expression, the type equals Type, and the size of the amount, ignoring its
+// sign, lies between MinAmount and MaxAmount inclusive. Empty conditions
+// match every transaction. Rules are tried by descending Priority and the
+// first match wins.
+type CategoryRule struct {
+	ID        uint `gorm:"primaryKey"`
+	Pattern   string
+	Type      string
+	MinAmount *Money `gorm:"column:min_amount_cents"`
+	MaxAmount *Money `gorm:"column:max_amount_cents"`
+	Category  string
+	Priority  int
+	CreatedAt time.Time
+}
+
+// String describes the rule's conditions, e.g.
+// `type Purchase, description ~ /COFFEE/, $0.00 to $10.00`
+func (r CategoryRule) String() string {
+	var conditions []string
+
+	if r.Type != "" {
+		conditions = append(conditions, "type "+r.Type)
+	}
+	if r.Pattern != "" {
+		conditions = append(conditions, "description ~ /"+r.Pattern+"/")
+	}
+
+	switch {
+	case r.MinAmount != nil && r.MaxAmount != nil:
+		conditions = append(conditions, r.MinAmount.Format(DefaultCurrency)+" to "+r.MaxAmount.Format(DefaultCurrency))
+	case r.MinAmount != nil:
+		conditions = append(conditions, "at least "+r.MinAmount.Format(DefaultCurrency))
+	case r.MaxAmount != nil:
+		conditions = append(conditions, "at most "+r.MaxAmount.Format(DefaultCurrency))
+	}
+
+	return strings.Join(conditions, ", ")
+}
+
+// Categorizer picks the category of transactions using the rules in the
+// database
+type Categorizer struct {
+	rules []compiledCategoryRule
+}
+
+type compiledCategoryRule struct {
+	CategoryRule
+	pattern *regexp.Regexp
+}
+
+// NewCategorizer loads the category rules, highest priority first
+func NewCategorizer(db *gorm.DB) (*Categorizer, error) {
+	var rules []CategoryRule
+	if err := db.Order("priority DESC, id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to load category rules: %w", err)
+	}
+
+	categorizer := &Categorizer{}
+	for _, rule := range rules {
+		compiled := compiledCategoryRule{CategoryRule: rule}
+
+		if rule.Pattern != "" {
+			pattern, err := compilePattern(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("category rule %d: %w", rule.ID, err)
+			}
+			compiled.pattern = pattern
+		}
+
+		categorizer.rules = append(categorizer.rules, compiled)
+	}
+
+	return categorizer, nil
+}
+
+// Categorize returns the category of the first rule matching the
+// transaction, or "" when none does
+func (c *Categorizer) Categorize(transaction Transaction) string {
+	amount := transaction.Amount
+	if amount < 0 {
+		amount = -amount
+	}
+
+	for _, rule := range c.rules {
+		switch {
+		case rule.Type != "" && !strings.EqualFold(rule.Type, transaction.Type):
+		case rule.pattern != nil && !rule.pattern.MatchString(transaction.Description):
+		case rule.MinAmount != nil && amount < *rule.MinAmount:
+		case rule.MaxAmount != nil && amount > *rule.MaxAmount:
+		default:
+			return rule.Category
+		}
+	}
+
+	return ""
+}
+
+// AddCategoryRule validates and saves a rule
+func AddCategoryRule(db *gorm.DB, rule *CategoryRule) error {
+	if strings.TrimSpace(rule.Category) == "" {
+		return fmt.Errorf("a category name is required")
+	}
+
+	if rule.Pattern == "" && rule.Type == "" && rule.MinAmount == nil && rule.MaxAmount == nil {
+		return fmt.Errorf("a pattern, type or amount is required")
+	}
+
+	if rule.Pattern != "" {
+		if _, err := compilePattern(rule.Pattern); err != nil {
+			return err
+		}
+	}
+
+	if (rule.MinAmount != nil && *rule.MinAmount < 0) || (rule.MaxAmount != nil && *rule.MaxAmount < 0) {
+		return fmt.Errorf("amounts are compared without their sign and can't be negative")
+	}
+
+	if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+		return fmt.Errorf("the minimum amount %s is more than the maximum %s",
+			rule.MinAmount.Format(DefaultCurrency), rule.MaxAmount.Format(DefaultCurrency))
+	}
+
+	return db.Create(rule).Error
+}
+
+// DeleteCategoryRule removes a rule
+func DeleteCategoryRule(db *gorm.DB, id uint) error {
+	result := db.Delete(&CategoryRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no category rule with ID %d", id)
+	}
+
+	return nil
+}
+
+// ListCategoryRules returns the rules in the order they are tried
+func ListCategoryRules(db *gorm.DB) ([]CategoryRule, error) {
+	var rules []CategoryRule
+	err := db.Order("priority DESC, id").Find(&rules).Error
+
+	return rules, err
+}
+
+// categoryBatchSize keeps the IDs of one update under SQLite's limit on
+// bound parameters
+const categoryBatchSize = 500
+
+// AssignCategories sets the category of every transaction, or with
+// onlyMissing of the transactions that have none yet, creating categories
+// as needed. Transactions no rule matches are left without a category, and
+// categories no transaction refers to anymore are removed. It returns the
+// number of transactions whose category changed.
+func AssignCategories(db *gorm.DB, onlyMissing bool) (int, error) {
+	categorizer, err := NewCategorizer(db)
+	if err != nil {
+		return 0, err
+	}
+
+	query := db.Select("id, description, type, amount_cents, category_id").Order("id")
+	if onlyMissing {
+		query = query.Where("category_id IS NULL")
+	}
+
+	var transactions []Transaction
+	if err := query.Find(&transactions).Error; err != nil {
+		return 0, fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	changed := 0
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		categories := map[string]uint{}
+
+		// The IDs of the transactions moving to each category, 0 for none
+		moves := map[uint][]uint{}
+		var order []uint
+
+		for _, transaction := range transactions {
+			var id uint
+
+			if name := categorizer.Categorize(transaction); name != "" {
+				var ok bool
+				if id, ok = categories[name]; !ok {
+					category := Category{Name: name}
+					if err := tx.Where(Category{Name: name}).FirstOrCreate(&category).Error; err != nil {
+						return fmt.Errorf("failed to find or create category %q: %w", name, err)
+					}
+					id, categories[name] = category.ID, category.ID
+				}
+			}
+
+			current := uint(0)
+			if transaction.CategoryID != nil {
+				current = *transaction.CategoryID
+			}
+			if current == id {
+				continue
+			}
+
+			if _, ok := moves[id]; !ok {
+				order = append(order, id)
+			}
+			moves[id] = append(moves[id], transaction.ID)
+		}
+
+		for _, id := range order {
+			var value interface{}
+			if id != 0 {
+				value = id
+			}
+
+			ids := moves[id]
+			for start := 0; start < len(ids); start += categoryBatchSize {
+				end := min(start+categoryBatchSize, len(ids))
+
+				update := tx.Model(&Transaction{}).Where("id IN ?", ids[start:end]).Update("category_id", value)
+				if update.Error != nil {
+					return fmt.Errorf("failed to assign categories: %w", update.Error)
+				}
+				changed += int(update.RowsAffected)
+			}
+		}
+
+		return tx.Where("id NOT IN (?)", tx.Model(&Transaction{}).Select("category_id").Where("category_id IS NOT NULL")).
+			Delete(&Category{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return changed, nil
+}
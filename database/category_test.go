@@ -0,0 +1,169 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newCategoryDB(t *testing.T, rules ...CategoryRule) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Category{}, &CategoryRule{}, &Transaction{}); err != nil {
+		t.Fatalf("failed to migrate database schema: %v", err)
+	}
+
+	for _, rule := range rules {
+		if err := AddCategoryRule(db, &rule); err != nil {
+			t.Fatalf("failed to add rule: %v", err)
+		}
+	}
+
+	return db
+}
+
+func money(amount Money) *Money {
+	return &amount
+}
+
+func TestCategorizer_Categorize(t *testing.T) {
+	db := newCategoryDB(t,
+		CategoryRule{Type: "Deposit", Category: "Income", Priority: -10},
+		CategoryRule{Pattern: `^uber\b`, Category: "Transportation"},
+		CategoryRule{Pattern: `uber\s*\*?\s*eats`, Category: "Dining", Priority: 10},
+		CategoryRule{Pattern: "best buy", MinAmount: money(50000), Category: "Electronics"},
+		CategoryRule{Type: "purchase", MaxAmount: money(500), Category: "Small Purchases", Priority: -5},
+	)
+
+	categorizer, err := NewCategorizer(db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, test := range []struct {
+		transaction Transaction
+		want        string
+	}{
+		{Transaction{Description: "UBER *EATS 8005928996", Type: "Purchase", Amount: -2410}, "Dining"},
+		{Transaction{Description: "UBER *TRIP HELP.UBER.COM", Type: "Purchase", Amount: -1800}, "Transportation"},
+		{Transaction{Description: "BEST BUY 00123", Type: "Purchase", Amount: -89999}, "Electronics"},
+		{Transaction{Description: "BEST BUY 00123", Type: "Purchase", Amount: -1299}, ""},
+		{Transaction{Description: "BEST BUY 00123", Type: "Purchase", Amount: -450}, "Small Purchases"},
+		{Transaction{Description: "Payroll", Type: "Deposit", Amount: 150000}, "Income"},
+		{Transaction{Description: "UBER *TRIP REFUND", Type: "Deposit", Amount: 1800}, "Transportation"},
+		{Transaction{Description: "Monthly fee", Type: "Fee", Amount: -500}, ""},
+	} {
+		if got := categorizer.Categorize(test.transaction); got != test.want {
+			t.Errorf("Categorize(%+v) = %q, want %q", test.transaction, got, test.want)
+		}
+	}
+}
+
+func TestAddCategoryRule_Invalid(t *testing.T) {
+	db := newCategoryDB(t)
+
+	for _, rule := range []CategoryRule{
+		{Pattern: "(", Category: "Broken"},
+		{Category: "Everything"},
+		{Type: "Purchase"},
+		{MinAmount: money(-100), Category: "Negative"},
+		{MinAmount: money(1000), MaxAmount: money(500), Category: "Empty Range"},
+	} {
+		if err := AddCategoryRule(db, &rule); err == nil {
+			t.Errorf("expected an error for %+v", rule)
+		}
+	}
+}
+
+func TestAssignCategories(t *testing.T) {
+	db := newCategoryDB(t,
+		CategoryRule{Pattern: "coffee", Category: "Dining"},
+		CategoryRule{Type: "Deposit", Category: "Income"},
+	)
+
+	date := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	transactions := []Transaction{
+		{Date: date, Description: "Blue Bottle Coffee", Type: "Purchase", Amount: -550},
+		{Date: date, Description: "Blue Bottle Coffee", Type: "Purchase", Amount: -650},
+		{Date: date, Description: "Payroll", Type: "Deposit", Amount: 150000},
+		{Date: date, Description: "Hardware Store", Type: "Purchase", Amount: -4200},
+	}
+	if err := db.Create(&transactions).Error; err != nil {
+		t.Fatalf("failed to seed database: %v", err)
+	}
+
+	categorized, err := AssignCategories(db, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if categorized != 3 {
+		t.Errorf("expected 3 transactions categorized, got %d", categorized)
+	}
+
+	spending, err := NewTransactionRepository(db).SpendingByCategory(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []CategorySpending{
+		{Category: "", Transactions: 1, Spent: 4200},
+		{Category: "Dining", Transactions: 2, Spent: 1200},
+		{Category: "Income", Transactions: 1, Spent: 0},
+	}
+	if !reflect.DeepEqual(spending, expected) {
+		t.Errorf("expected %+v, got %+v", expected, spending)
+	}
+
+	// Rules changing takes a full pass: the coffee rule goes, a store rule
+	// comes, and nothing is left in Dining
+	if err := DeleteCategoryRule(db, 1); err != nil {
+		t.Fatalf("failed to delete rule: %v", err)
+	}
+
+	if err := AddCategoryRule(db, &CategoryRule{Pattern: "store", Category: "Home"}); err != nil {
+		t.Fatalf("failed to add rule: %v", err)
+	}
+
+	if categorized, err = AssignCategories(db, true); err != nil || categorized != 1 {
+		t.Errorf("expected only the uncategorized transaction to change, got %d (%v)", categorized, err)
+	}
+
+	if categorized, err = AssignCategories(db, false); err != nil || categorized != 2 {
+		t.Errorf("expected the coffee transactions to lose their category, got %d (%v)", categorized, err)
+	}
+
+	var uncategorized int64
+	if err := db.Model(&Transaction{}).Where("category_id IS NULL").Count(&uncategorized).Error; err != nil {
+		t.Fatalf("failed to count transactions: %v", err)
+	}
+
+	if uncategorized != 2 {
+		t.Errorf("expected 2 transactions without a category, got %d", uncategorized)
+	}
+
+	var categories []string
+	if err := db.Model(&Category{}).Order("name").Pluck("name", &categories).Error; err != nil {
+		t.Fatalf("failed to list categories: %v", err)
+	}
+
+	if !reflect.DeepEqual(categories, []string{"Home", "Income"}) {
+		t.Errorf("unexpected categories %v", categories)
+	}
+}
+
+func TestCategoryRule_String(t *testing.T) {
+	rule := CategoryRule{Type: "Purchase", Pattern: "COFFEE", MinAmount: money(0), MaxAmount: money(1000)}
+
+	if got, want := rule.String(), "type Purchase, description ~ /COFFEE/, $0.00 to $10.00"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Conversation is a chat session with the assistant
+type Conversation struct {
+	ID        uint `gorm:"primaryKey"`
+	Title     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Message is one message of a conversation: a question, an answer, the
+// model's tool calls or a tool's result
+type Message struct {
+	ID             uint `gorm:"primaryKey"`
+	ConversationID uint `gorm:"index"`
+	Position       int  // Order within the conversation, from 0
+	Role           string
+	Content        string
+	Name           string
+	ToolCallID     string
+	ToolCalls      string // JSON encoded tool calls, which hold the SQL the model ran
+	CreatedAt      time.Time
+}
+
+// ConversationSummary describes a conversation for listing
+type ConversationSummary struct {
+	ID        uint
+	Title     string
+	Messages  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// AppendMessages adds messages to the end of a conversation and marks the
+// conversation as updated
+func AppendMessages(db *gorm.DB, conversationID uint, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var next int64
+		if err := tx.Model(&Message{}).Where("conversation_id = ?", conversationID).Count(&next).Error; err != nil {
+			return err
+		}
+
+		for i := range messages {
+			messages[i].ID = 0
+			messages[i].ConversationID = conversationID
+			messages[i].Position = int(next) + i
+		}
+
+		if err := tx.Create(&messages).Error; err != nil {
+			return fmt.Errorf("failed to save messages: %w", err)
+		}
+
+		return tx.Model(&Conversation{ID: conversationID}).Update("updated_at", time.Now()).Error
+	})
+}
+
+// ListConversations returns every conversation, most recently updated first
+func ListConversations(db *gorm.DB) ([]ConversationSummary, error) {
+	var conversations []ConversationSummary
+
+	err := db.Model(&Conversation{}).
+		Select(`conversations.id, conversations.title, conversations.created_at, conversations.updated_at,
+			(SELECT count(*) FROM messages WHERE messages.conversation_id = conversations.id) AS messages`).
+		Order("conversations.updated_at DESC, conversations.id DESC").
+		Scan(&conversations).Error
+
+	return conversations, err
+}
+
+// LoadConversation returns a conversation and its messages in order
+func LoadConversation(db *gorm.DB, id uint) (*Conversation, []Message, error) {
+	var conversations []Conversation
+	if err := db.Where("id = ?", id).Limit(1).Find(&conversations).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if len(conversations) == 0 {
+		return nil, nil, fmt.Errorf("no conversation with ID %d", id)
+	}
+
+	var messages []Message
+	if err := db.Where("conversation_id = ?", id).Order("position").Find(&messages).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &conversations[0], messages, nil
+}
+
+// DeleteConversation removes a conversation and its messages
+func DeleteConversation(db *gorm.DB, id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&Conversation{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("no conversation with ID %d", id)
+		}
+
+		return tx.Where("conversation_id = ?", id).Delete(&Message{}).Error
+	})
+}
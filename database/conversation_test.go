@@ -0,0 +1,99 @@
+package database
+
+import (
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newConversationDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Conversation{}, &Message{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	return db
+}
+
+func TestConversationHistory(t *testing.T) {
+	db := newConversationDB(t)
+
+	first := Conversation{Title: "Spending by month"}
+	second := Conversation{Title: "Subscriptions"}
+	for _, conversation := range []*Conversation{&first, &second} {
+		if err := db.Create(conversation).Error; err != nil {
+			t.Fatalf("failed to seed database: %v", err)
+		}
+	}
+
+	if err := AppendMessages(db, first.ID, []Message{
+		{Role: "user", Content: "How has my spending changed?"},
+		{Role: "assistant", ToolCalls: `[{"id":"call_1","function":{"arguments":"{\"sql\":\"SELECT 1\"}"}}]`},
+	}); err != nil {
+		t.Fatalf("AppendMessages returned an error: %v", err)
+	}
+
+	if err := AppendMessages(db, first.ID, []Message{
+		{Role: "tool", ToolCallID: "call_1", Content: "1"},
+		{Role: "assistant", Content: "It went up."},
+	}); err != nil {
+		t.Fatalf("AppendMessages returned an error: %v", err)
+	}
+
+	conversations, err := ListConversations(db)
+	if err != nil {
+		t.Fatalf("ListConversations returned an error: %v", err)
+	}
+
+	// The first conversation was updated last, so it's listed first
+	if len(conversations) != 2 || conversations[0].ID != first.ID || conversations[0].Messages != 4 || conversations[1].Messages != 0 {
+		t.Fatalf("unexpected conversations %+v", conversations)
+	}
+
+	conversation, messages, err := LoadConversation(db, first.ID)
+	if err != nil {
+		t.Fatalf("LoadConversation returned an error: %v", err)
+	}
+
+	if conversation.Title != "Spending by month" || len(messages) != 4 {
+		t.Fatalf("unexpected conversation %+v with %d messages", conversation, len(messages))
+	}
+
+	for i, message := range messages {
+		if message.Position != i {
+			t.Errorf("message %d has position %d", i, message.Position)
+		}
+	}
+
+	if messages[2].ToolCallID != "call_1" || messages[3].Content != "It went up." {
+		t.Errorf("unexpected messages %+v", messages)
+	}
+
+	if err := DeleteConversation(db, first.ID); err != nil {
+		t.Fatalf("DeleteConversation returned an error: %v", err)
+	}
+
+	if _, _, err := LoadConversation(db, first.ID); err == nil {
+		t.Error("expected an error loading a deleted conversation")
+	}
+
+	var remaining int64
+	if err := db.Model(&Message{}).Count(&remaining).Error; err != nil {
+		t.Fatalf("failed to count messages: %v", err)
+	}
+
+	if remaining != 0 {
+		t.Errorf("expected the messages to be deleted, %d remain", remaining)
+	}
+
+	if err := DeleteConversation(db, first.ID); err == nil {
+		t.Error("expected an error deleting a missing conversation")
+	}
+}
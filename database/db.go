@@ -5,8 +5,38 @@ import (
 	"gorm.io/gorm"
 )
 
+// DefaultPath is the database both the importer and the app use unless
+// configured otherwise
+const DefaultPath = "transactions.db"
+
 func GetDBConnection() (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open("transactions.db"), &gorm.Config{})
+	return Open(DefaultPath)
+}
+
+// Open opens the database at path for reading and writing, creating it if
+// it doesn't exist. Transactions take the write lock when they begin, so a
+// transaction that reads before it writes waits for other writers, for up
+// to the busy timeout, instead of deadlocking with them.
+func Open(path string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(path+"?_busy_timeout=5000&_txlock=immediate"), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// GetReadOnlyDBConnection opens transactions.db read-only, for running
+// queries we didn't write ourselves
+func GetReadOnlyDBConnection() (*gorm.DB, error) {
+	return OpenReadOnly(DefaultPath)
+}
+
+// OpenReadOnly opens the database at path so that nothing, not even a
+// PRAGMA, can write to it. The file is opened with mode=ro and the
+// connection additionally sets query_only.
+func OpenReadOnly(path string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open("file:"+path+"?mode=ro&_query_only=true"), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// LedgerView is the name of the view that presents transactions with
+// amounts in major units (dollars), which is what the model queries
+const LedgerView = "ledger"
+
+// ledgerViewSQL is the latest definition of the view. Changing it takes a
+// migration that replaces the view, see migrations.go.
+const ledgerViewSQL = ledgerViewV7
+
+// CreateLedgerView (re)creates the ledger view so it matches the current
+// transactions table
+func CreateLedgerView(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return replaceLedgerView(tx, ledgerViewSQL)
+	})
+}
+
+// replaceLedgerView drops the ledger view and creates it from definition
+func replaceLedgerView(tx *gorm.DB, definition string) error {
+	if err := tx.Exec("DROP VIEW IF EXISTS " + LedgerView).Error; err != nil {
+		return fmt.Errorf("failed to drop %s view: %w", LedgerView, err)
+	}
+
+	if err := tx.Exec(definition).Error; err != nil {
+		return fmt.Errorf("failed to create %s view: %w", LedgerView, err)
+	}
+
+	return nil
+}
+
+// MigrateLegacyAmounts converts databases created before amounts were stored
+// in cents. The old float amount and net_amount columns are rounded into
+// amount_cents and net_amount_cents, which must already exist, and then
+// dropped. It does nothing on databases without the old columns.
+func MigrateLegacyAmounts(db *gorm.DB) error {
+	// Like every migration step, this names the table rather than use the
+	// Transaction model, which keeps changing
+	if !db.Migrator().HasColumn("transactions", "amount") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE transactions SET
+			amount_cents = CAST(ROUND(amount * 100) AS INTEGER),
+			net_amount_cents = CAST(ROUND(net_amount * 100) AS INTEGER),
+			currency = COALESCE(NULLIF(currency, ''), ?)`, DefaultCurrency).Error; err != nil {
+			return fmt.Errorf("failed to convert amounts to cents: %w", err)
+		}
+
+		for _, column := range []string{"amount", "net_amount"} {
+			if err := tx.Exec("ALTER TABLE transactions DROP COLUMN " + column).Error; err != nil {
+				return fmt.Errorf("failed to drop legacy %s column: %w", column, err)
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrateLegacyAmounts(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	// The schema and data as written by importers that stored float amounts
+	if err := db.Exec(`CREATE TABLE transactions (
+		id integer primary key, date datetime, description text, type text,
+		amount real, net_amount real, settle_date datetime)`).Error; err != nil {
+		t.Fatalf("failed to create legacy table: %v", err)
+	}
+
+	if err := db.Exec(`INSERT INTO transactions (date, description, type, amount, net_amount, settle_date) VALUES
+		('2024-07-19 00:00:00+00:00', 'Islandadv.Whalewatch', 'Purchase', -274.18, -274.18, '2024-07-20 00:00:00+00:00'),
+		('2024-07-19 00:00:00+00:00', 'Notion Labs, Inc.', 'Purchase', -11.03, -11.03, '2024-07-20 00:00:00+00:00')`).Error; err != nil {
+		t.Fatalf("failed to seed legacy table: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Transaction{}); err != nil {
+		t.Fatalf("failed to migrate database schema: %v", err)
+	}
+
+	if err := MigrateLegacyAmounts(db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if db.Migrator().HasColumn(&Transaction{}, "amount") {
+		t.Errorf("expected the legacy amount column to be dropped")
+	}
+
+	var transactions []Transaction
+	if err := db.Order("id").Find(&transactions).Error; err != nil {
+		t.Fatalf("failed to read transactions: %v", err)
+	}
+
+	if len(transactions) != 2 || transactions[0].Amount != -27418 || transactions[1].NetAmount != -1103 || transactions[0].Currency != "USD" {
+		t.Errorf("unexpected transactions after migration: %+v", transactions)
+	}
+
+	// Running it again is a no-op
+	if err := MigrateLegacyAmounts(db); err != nil {
+		t.Errorf("unexpected error on second run: %v", err)
+	}
+}
+
+func TestCreateLedgerView(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Account{}, &Merchant{}, &Category{}, &Transaction{}); err != nil {
+		t.Fatalf("failed to migrate database schema: %v", err)
+	}
+
+	if err := db.Create(&Transaction{Description: "Coffee", Amount: -450, NetAmount: -450}).Error; err != nil {
+		t.Fatalf("failed to seed database: %v", err)
+	}
+
+	// Creating the view twice replaces it
+	for i := 0; i < 2; i++ {
+		if err := CreateLedgerView(db); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	result, err := NewTransactionRepository(db).ExecuteRawQuery("SELECT amount, net_amount FROM ledger")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(result))
+	}
+
+	// Columns computed in the view have no declared type, so they are scanned
+	// into *interface{}
+	if amount, ok := result[0]["amount"].(*interface{}); !ok || *amount != -4.5 {
+		t.Errorf("expected a dollar amount of -4.5 from the ledger view, got %v", result[0]["amount"])
+	}
+}
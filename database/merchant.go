@@ -0,0 +1,274 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Merchant is the canonical name of a business, shared by every description
+// the bank uses for it, e.g. "Amazon" for "AMZN Mktp US*2K4" and
+// "AMAZON.COM*MB1LX SEATTLE WA"
+type Merchant struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"uniqueIndex"`
+	CreatedAt time.Time
+}
+
+// MerchantRule maps descriptions matching Pattern, a case-insensitive
+// regular expression, to a merchant. Rules are tried by descending Priority
+// before a description is cleaned up with CleanDescription.
+type MerchantRule struct {
+	ID        uint `gorm:"primaryKey"`
+	Pattern   string
+	Merchant  string
+	Priority  int
+	CreatedAt time.Time
+}
+
+// processorPrefix matches the card processor a merchant charged through,
+// as in "SQ *BLUE BOTTLE" (Square) or "TST* SHAKE SHACK" (Toast)
+var processorPrefix = regexp.MustCompile(`(?i)^(SQ|TST|PP|PAYPAL|SP|IN|DD|PY|CKO|GRUBHUB|LS|FS)\s*\*\s*`)
+
+// storeNumber matches store and terminal numbers such as "#1234",
+// "STORE 1234" and "0045". Whatever follows is usually the city.
+var storeNumber = regexp.MustCompile(`(?i)\s+(#\s*\d+|(STORE|STR|NO\.?|UNIT)\s*#?\s*\d+|\d{3,}).*$`)
+
+// domain matches a web address suffix and whatever follows it, such as
+// ".COM/BILL"
+var domain = regexp.MustCompile(`(?i)\.(COM|NET|ORG|CO|IO)\b.*$`)
+
+// legalSuffix matches company suffixes such as ", Inc." and "LLC". "Co" is
+// left alone since it ends names like "Smith and Co" too.
+var legalSuffix = regexp.MustCompile(`(?i)[\s,]+(INC|LLC|LTD|CORP|CORPORATION|INCORPORATED)\.?$`)
+
+// stateCodes are the two letter codes that end processor descriptions with a
+// city
+var stateCodes = map[string]bool{}
+
+func init() {
+	for _, code := range strings.Fields(`AL AK AZ AR CA CO CT DE DC FL GA HI ID IL IN IA KS KY LA ME MD MA MI
+		MN MS MO MT NE NV NH NJ NM NY NC ND OH OK OR PA RI SC SD TN TX UT VT VA WA WV WI WY PR`) {
+		stateCodes[code] = true
+	}
+}
+
+// CleanDescription strips what banks add to a merchant's name: card
+// processor prefixes, order references after "*", web domains, store
+// numbers and the city and state after them, the city and state that end
+// descriptions from card processors, and legal suffixes such as "Inc.".
+// All caps names are title cased. Descriptions with nothing left after
+// cleaning are returned trimmed.
+func CleanDescription(description string) string {
+	name := strings.Join(strings.Fields(description), " ")
+
+	processed := processorPrefix.MatchString(name)
+	name = processorPrefix.ReplaceAllString(name, "")
+
+	if before, _, found := strings.Cut(name, "*"); found && strings.TrimSpace(before) != "" {
+		name = before
+	}
+
+	name = domain.ReplaceAllString(name, "")
+	name = storeNumber.ReplaceAllString(name, "")
+
+	// Card processors end "SQ *BLUE BOTTLE OAKLAND CA" with a city and state,
+	// the city is assumed to be the word before the state. Elsewhere a
+	// trailing "OK", "IN" or "ME" is as likely part of the name.
+	if words := strings.Fields(name); processed && len(words) >= 3 && stateCodes[strings.ToUpper(words[len(words)-1])] {
+		name = strings.Join(words[:len(words)-2], " ")
+	}
+
+	for {
+		trimmed := strings.TrimSpace(legalSuffix.ReplaceAllString(name, ""))
+		trimmed = strings.TrimRight(trimmed, " ,-")
+		if trimmed == name {
+			break
+		}
+		name = trimmed
+	}
+
+	if name == "" {
+		return strings.Join(strings.Fields(description), " ")
+	}
+
+	if name == strings.ToUpper(name) {
+		name = titleCase(name)
+	}
+
+	return name
+}
+
+// titleCase capitalizes the first letter of each word, and of each part of a
+// hyphenated word such as "7-Eleven", and lowers the rest
+func titleCase(s string) string {
+	words := strings.Fields(strings.ToLower(s))
+	for i, word := range words {
+		parts := strings.Split(word, "-")
+		for j, part := range parts {
+			if part != "" {
+				parts[j] = strings.ToUpper(part[:1]) + part[1:]
+			}
+		}
+		words[i] = strings.Join(parts, "-")
+	}
+
+	return strings.Join(words, " ")
+}
+
+// MerchantNormalizer maps descriptions to canonical merchant names using the
+// rules in the database, falling back to CleanDescription
+type MerchantNormalizer struct {
+	rules []compiledMerchantRule
+}
+
+type compiledMerchantRule struct {
+	pattern  *regexp.Regexp
+	merchant string
+}
+
+// NewMerchantNormalizer loads the merchant rules, highest priority first
+func NewMerchantNormalizer(db *gorm.DB) (*MerchantNormalizer, error) {
+	var rules []MerchantRule
+	if err := db.Order("priority DESC, id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to load merchant rules: %w", err)
+	}
+
+	normalizer := &MerchantNormalizer{}
+	for _, rule := range rules {
+		pattern, err := compilePattern(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("merchant rule %d: %w", rule.ID, err)
+		}
+
+		normalizer.rules = append(normalizer.rules, compiledMerchantRule{pattern: pattern, merchant: rule.Merchant})
+	}
+
+	return normalizer, nil
+}
+
+// Normalize returns the merchant a description belongs to
+func (n *MerchantNormalizer) Normalize(description string) string {
+	for _, rule := range n.rules {
+		if rule.pattern.MatchString(description) {
+			return rule.merchant
+		}
+	}
+
+	return CleanDescription(description)
+}
+
+// AddMerchantRule validates and saves a rule
+func AddMerchantRule(db *gorm.DB, rule *MerchantRule) error {
+	if strings.TrimSpace(rule.Merchant) == "" {
+		return fmt.Errorf("a merchant name is required")
+	}
+
+	if _, err := compilePattern(rule.Pattern); err != nil {
+		return err
+	}
+
+	return db.Create(rule).Error
+}
+
+// DeleteMerchantRule removes a rule
+func DeleteMerchantRule(db *gorm.DB, id uint) error {
+	result := db.Delete(&MerchantRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no merchant rule with ID %d", id)
+	}
+
+	return nil
+}
+
+// ListMerchantRules returns the rules in the order they are tried
+func ListMerchantRules(db *gorm.DB) ([]MerchantRule, error) {
+	var rules []MerchantRule
+	err := db.Order("priority DESC, id").Find(&rules).Error
+
+	return rules, err
+}
+
+// compilePattern compiles a rule's pattern, matched case-insensitively
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("a pattern is required")
+	}
+
+	compiled, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	return compiled, nil
+}
+
+// AssignMerchants sets the merchant of every transaction, or with onlyMissing
+// of the transactions that have none yet, creating merchants as needed.
+// Merchants no transaction refers to anymore are removed. It returns the
+// number of transactions whose merchant changed.
+func AssignMerchants(db *gorm.DB, onlyMissing bool) (int, error) {
+	normalizer, err := NewMerchantNormalizer(db)
+	if err != nil {
+		return 0, err
+	}
+
+	query := db.Model(&Transaction{}).Distinct("description")
+	if onlyMissing {
+		query = query.Where("merchant_id IS NULL")
+	}
+
+	var descriptions []string
+	if err := query.Pluck("description", &descriptions).Error; err != nil {
+		return 0, fmt.Errorf("failed to list descriptions: %w", err)
+	}
+	sort.Strings(descriptions)
+
+	changed := 0
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		merchants := map[string]uint{}
+
+		for _, description := range descriptions {
+			name := normalizer.Normalize(description)
+
+			id, ok := merchants[name]
+			if !ok {
+				merchant := Merchant{Name: name}
+				if err := tx.Where(Merchant{Name: name}).FirstOrCreate(&merchant).Error; err != nil {
+					return fmt.Errorf("failed to find or create merchant %q: %w", name, err)
+				}
+				id, merchants[name] = merchant.ID, merchant.ID
+			}
+
+			update := tx.Model(&Transaction{}).Where("description = ?", description)
+			if onlyMissing {
+				update = update.Where("merchant_id IS NULL")
+			} else {
+				update = update.Where("merchant_id IS NULL OR merchant_id <> ?", id)
+			}
+
+			update = update.Update("merchant_id", id)
+			if update.Error != nil {
+				return fmt.Errorf("failed to assign merchant %q: %w", name, update.Error)
+			}
+			changed += int(update.RowsAffected)
+		}
+
+		return tx.Where("id NOT IN (?)", tx.Model(&Transaction{}).Select("merchant_id").Where("merchant_id IS NOT NULL")).
+			Delete(&Merchant{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return changed, nil
+}
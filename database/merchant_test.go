@@ -0,0 +1,182 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCleanDescription(t *testing.T) {
+	cases := map[string]string{
+		"SQ *BLUE BOTTLE COFFEE OAKLAND CA": "Blue Bottle Coffee",
+		"TST* SHAKE SHACK 1234 NEW YORK NY": "Shake Shack",
+		"STARBUCKS STORE 12345 SEATTLE WA":  "Starbucks",
+		"WHOLEFDS MKT #10234":               "Wholefds Mkt",
+		"Notion Labs, Inc.":                 "Notion Labs",
+		"NETFLIX.COM":                       "Netflix",
+		"AMAZON.COM*MB1LX SEATTLE WA":       "Amazon",
+		"PAYPAL *SPOTIFYUSAI":               "Spotifyusai",
+		"Whalewatch Tours":                  "Whalewatch Tours",
+		"7-ELEVEN 35512":                    "7-Eleven",
+		"  Payroll  ":                       "Payroll",
+		"TAKE ME OUT OK":                    "Take Me Out Ok",
+		"SMITH AND CO":                      "Smith And Co",
+		"PLUG IT IN":                        "Plug It In",
+		"SQ *TAKE ME OUT TULSA OK":          "Take Me Out",
+		"#1234":                             "#1234",
+	}
+
+	for description, want := range cases {
+		if got := CleanDescription(description); got != want {
+			t.Errorf("CleanDescription(%q) = %q, want %q", description, got, want)
+		}
+	}
+}
+
+func newMerchantDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Merchant{}, &MerchantRule{}, &Transaction{}); err != nil {
+		t.Fatalf("failed to migrate database schema: %v", err)
+	}
+
+	return db
+}
+
+func TestMerchantNormalizer_Priority(t *testing.T) {
+	db := newMerchantDB(t)
+
+	for _, rule := range []MerchantRule{
+		{Pattern: `^uber\b`, Merchant: "Uber"},
+		{Pattern: `uber\s*\*?\s*eats`, Merchant: "Uber Eats", Priority: 10},
+	} {
+		if err := AddMerchantRule(db, &rule); err != nil {
+			t.Fatalf("failed to add rule: %v", err)
+		}
+	}
+
+	normalizer, err := NewMerchantNormalizer(db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for description, want := range map[string]string{
+		"UBER *EATS 8005928996": "Uber Eats",
+		"UBER *TRIP HELP.UBER":  "Uber",
+		"SQ *DUMPLING HOUSE":    "Dumpling House",
+	} {
+		if got := normalizer.Normalize(description); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", description, got, want)
+		}
+	}
+}
+
+func TestAddMerchantRule_Invalid(t *testing.T) {
+	db := newMerchantDB(t)
+
+	for _, rule := range []MerchantRule{
+		{Pattern: "(", Merchant: "Broken"},
+		{Pattern: "", Merchant: "Empty"},
+		{Pattern: "AMZN"},
+	} {
+		if err := AddMerchantRule(db, &rule); err == nil {
+			t.Errorf("expected an error for %+v", rule)
+		}
+	}
+}
+
+func TestAssignMerchants(t *testing.T) {
+	db := newMerchantDB(t)
+
+	date := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	transactions := []Transaction{
+		{Date: date, Description: "AMZN Mktp US*2K4", Amount: -2599},
+		{Date: date, Description: "AMAZON.COM*MB1LX SEATTLE WA", Amount: -1200},
+		{Date: date, Description: "STARBUCKS STORE 12345 SEATTLE WA", Amount: -550},
+		{Date: date, Description: "STARBUCKS STORE 999 PORTLAND OR", Amount: -600},
+		{Date: date, Description: "STARBUCKS STORE 999 PORTLAND OR", Amount: -700},
+	}
+	if err := db.Create(&transactions).Error; err != nil {
+		t.Fatalf("failed to seed database: %v", err)
+	}
+
+	assigned, err := AssignMerchants(db, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if assigned != len(transactions) {
+		t.Errorf("expected %d transactions assigned, got %d", len(transactions), assigned)
+	}
+
+	// Without a rule the two Amazon descriptions clean up differently
+	top, err := NewTransactionRepository(db).TopMerchants(5, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []DescriptionTotal{
+		{Description: "AMZN Mktp US", TotalSpent: 2599},
+		{Description: "Starbucks", TotalSpent: 1850},
+		{Description: "Amazon", TotalSpent: 1200},
+	}
+	if !reflect.DeepEqual(top, expected) {
+		t.Errorf("expected %+v, got %+v", expected, top)
+	}
+
+	if err := AddMerchantRule(db, &MerchantRule{Pattern: `\bAMZN\b|AMAZON`, Merchant: "Amazon"}); err != nil {
+		t.Fatalf("failed to add rule: %v", err)
+	}
+
+	// Only missing merchants are assigned, so the rule needs a full backfill.
+	// A new transaction with an old description gets the new merchant and
+	// leaves the others alone.
+	if err := db.Create(&Transaction{Date: date, Description: "AMZN Mktp US*2K4", Amount: -100}).Error; err != nil {
+		t.Fatalf("failed to seed database: %v", err)
+	}
+
+	if assigned, err = AssignMerchants(db, true); err != nil || assigned != 1 {
+		t.Errorf("expected only the new transaction assigned, got %d (%v)", assigned, err)
+	}
+
+	var stale int64
+	if err := db.Model(&Transaction{}).Joins("JOIN merchants ON merchants.id = transactions.merchant_id").
+		Where("description = ? AND merchants.name = ?", "AMZN Mktp US*2K4", "AMZN Mktp US").Count(&stale).Error; err != nil {
+		t.Fatalf("failed to count transactions: %v", err)
+	}
+
+	if stale != 1 {
+		t.Errorf("expected the existing transaction to keep its merchant, got %d", stale)
+	}
+
+	if assigned, err = AssignMerchants(db, false); err != nil || assigned != 1 {
+		t.Errorf("expected 1 transaction reassigned, got %d (%v)", assigned, err)
+	}
+
+	top, err = NewTransactionRepository(db).TopMerchants(1, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(top) != 1 || top[0] != (DescriptionTotal{Description: "Amazon", TotalSpent: 3899}) {
+		t.Errorf("expected Amazon to combine both descriptions, got %+v", top)
+	}
+
+	// The merchant only the old description used is gone
+	var merchants []string
+	if err := db.Model(&Merchant{}).Order("name").Pluck("name", &merchants).Error; err != nil {
+		t.Fatalf("failed to list merchants: %v", err)
+	}
+
+	if !reflect.DeepEqual(merchants, []string{"Amazon", "Starbucks"}) {
+		t.Errorf("unexpected merchants %v", merchants)
+	}
+}
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaMigration records a migration applied to the database
+type SchemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migration is one numbered step of the schema's history. Up applies it and
+// Down reverts it, a nil Down meaning it can't be reverted. Each runs in a
+// transaction. Steps spell out the schema they expect rather than use the
+// models, since the models keep changing after a step is written.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// MigrationStatus tells whether a migration has been applied to a database
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // Nil while the migration is pending
+}
+
+// LatestVersion is the schema version this build expects
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrate applies every pending migration
+func Migrate(db *gorm.DB) error {
+	return MigrateTo(db, LatestVersion())
+}
+
+// MigrateTo applies the pending migrations up to and including version, then
+// reverts the applied ones after it, newest first
+func MigrateTo(db *gorm.DB, version int) error {
+	if version < 0 || version > LatestVersion() {
+		return fmt.Errorf("unknown schema version %d, the latest is %d", version, LatestVersion())
+	}
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > version {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+
+			return tx.Create(&SchemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+			continue
+		}
+
+		if migration.Down == nil {
+			return fmt.Errorf("migration %d (%s) can't be reverted", migration.Version, migration.Name)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+
+			return tx.Delete(&SchemaMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return fmt.Errorf("reverting migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// CheckVersion returns an error asking for chime-ai db migrate unless every
+// migration this build knows has been applied to db. It only reads, so
+// commands that open the database read-only can check it before querying.
+func CheckVersion(db *gorm.DB) error {
+	var tables int64
+	if err := db.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", SchemaMigration{}.TableName()).
+		Scan(&tables).Error; err != nil {
+		return fmt.Errorf("failed to read the database schema: %w", err)
+	}
+
+	var versions []int
+	if tables > 0 {
+		if err := db.Model(&SchemaMigration{}).Pluck("version", &versions).Error; err != nil {
+			return fmt.Errorf("failed to read the applied migrations: %w", err)
+		}
+	}
+
+	applied := map[int]bool{}
+	for _, version := range versions {
+		if version > LatestVersion() {
+			return newerSchemaError(version)
+		}
+		applied[version] = true
+	}
+
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			return fmt.Errorf("the database schema is out of date, run chime-ai db migrate to update it")
+		}
+	}
+
+	return nil
+}
+
+// ListMigrations returns every migration this build knows, oldest first,
+// and when each was applied to db
+func ListMigrations(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, migration := range migrations {
+		statuses[i] = MigrationStatus{Version: migration.Version, Name: migration.Name}
+
+		if record, ok := applied[migration.Version]; ok {
+			statuses[i].AppliedAt = &record.AppliedAt
+		}
+	}
+
+	return statuses, nil
+}
+
+// appliedMigrations creates the schema_migrations table if needed and
+// returns its records by version. Databases migrated by a newer build are
+// refused, since this one can't know what their schema looks like.
+func appliedMigrations(db *gorm.DB) (map[int]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var records []SchemaMigration
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]SchemaMigration, len(records))
+	for _, record := range records {
+		if record.Version > LatestVersion() {
+			return nil, newerSchemaError(record.Version)
+		}
+
+		applied[record.Version] = record
+	}
+
+	return applied, nil
+}
+
+// newerSchemaError refuses a database migrated by a newer build
+func newerSchemaError(version int) error {
+	return fmt.Errorf("the database is at schema version %d but this build only knows up to %d, update chime-ai",
+		version, LatestVersion())
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func newMigrationDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := Open(filepath.Join(t.TempDir(), "transactions.db"))
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	return db
+}
+
+func hasView(t *testing.T, db *gorm.DB) bool {
+	t.Helper()
+
+	var views int64
+	if err := db.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'view' AND name = ?", LedgerView).Scan(&views).Error; err != nil {
+		t.Fatalf("failed to look up the %s view: %v", LedgerView, err)
+	}
+
+	return views == 1
+}
+
+func TestMigrate(t *testing.T) {
+	db := newMigrationDB(t)
+
+	// Migrating an up to date database changes nothing
+	for i := 0; i < 2; i++ {
+		if err := Migrate(db); err != nil {
+			t.Fatalf("migration %d failed: %v", i+1, err)
+		}
+	}
+
+	for _, table := range []string{"accounts", "imports", "transactions", "usages", "conversations", "messages"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("expected the %s table to exist", table)
+		}
+	}
+
+	if !hasView(t, db) {
+		t.Errorf("expected the %s view to exist", LedgerView)
+	}
+
+	statuses, err := ListMigrations(db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(statuses) != LatestVersion() {
+		t.Fatalf("expected %d migrations, got %d", LatestVersion(), len(statuses))
+	}
+
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("expected migration %d to be applied", status.Version)
+		}
+	}
+}
+
+func TestMigrate_UnversionedDatabase(t *testing.T) {
+	db := newMigrationDB(t)
+
+	// A database written by the importer before migrations were versioned,
+	// when amounts were floats
+	for _, statement := range []string{
+		`CREATE TABLE transactions (id integer PRIMARY KEY AUTOINCREMENT, date datetime, description text,
+			type text, amount real, net_amount real, settle_date datetime)`,
+		`INSERT INTO transactions (date, description, type, amount, net_amount, settle_date)
+			VALUES ('2024-07-03 00:00:00+00:00', 'Coffee', 'Purchase', -4.5, -4.5, '2024-07-03 00:00:00+00:00')`,
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("failed to set up legacy database: %v", err)
+		}
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var row struct {
+		AmountCents int64
+		Currency    string
+		Amount      float64
+	}
+	if err := db.Raw("SELECT t.amount_cents, t.currency, l.amount FROM transactions t JOIN ledger l ON l.id = t.id").Scan(&row).Error; err != nil {
+		t.Fatalf("failed to read migrated transaction: %v", err)
+	}
+
+	if row.AmountCents != -450 || row.Currency != DefaultCurrency || row.Amount != -4.5 {
+		t.Errorf("unexpected migrated transaction: %+v", row)
+	}
+}
+
+func TestMigrateTo_Reverts(t *testing.T) {
+	db := newMigrationDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := MigrateTo(db, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if hasView(t, db) || db.Migrator().HasTable("usages") || db.Migrator().HasTable("conversations") {
+		t.Error("expected the migrations after version 2 to be reverted")
+	}
+
+	if !db.Migrator().HasTable("transactions") {
+		t.Error("expected the transactions table to be kept")
+	}
+
+	statuses, err := ListMigrations(db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, status := range statuses {
+		if applied := status.AppliedAt != nil; applied != (status.Version <= 2) {
+			t.Errorf("migration %d applied = %v after migrating to version 2", status.Version, applied)
+		}
+	}
+
+	// The first migrations create the user's data and can't be reverted
+	if err := MigrateTo(db, 0); err == nil || !strings.Contains(err.Error(), "can't be reverted") {
+		t.Errorf("expected an irreversible migration error, got %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("failed to migrate back up: %v", err)
+	}
+
+	if !hasView(t, db) {
+		t.Errorf("expected the %s view to be recreated", LedgerView)
+	}
+}
+
+func TestMigrate_NewerDatabase(t *testing.T) {
+	db := newMigrationDB(t)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := db.Create(&SchemaMigration{Version: LatestVersion() + 1, Name: "from the future"}).Error; err != nil {
+		t.Fatalf("failed to record migration: %v", err)
+	}
+
+	if err := Migrate(db); err == nil {
+		t.Error("expected an error for a database migrated by a newer build")
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.db")
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	readOnly, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	for _, version := range []int{0, 5} {
+		if version > 0 {
+			if err := MigrateTo(db, version); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		if err := CheckVersion(readOnly); err == nil || !strings.Contains(err.Error(), "chime-ai db migrate") {
+			t.Errorf("expected an error asking to migrate at version %d, got %v", version, err)
+		}
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := CheckVersion(readOnly); err != nil {
+		t.Errorf("unexpected error for an up to date database: %v", err)
+	}
+}
@@ -0,0 +1,342 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrations is the schema's history, oldest first. Append new steps with
+// the next version; never edit a step that has been released.
+var migrations = []Migration{
+	{
+		// Databases written before migrations were versioned already have
+		// some or all of these tables, AutoMigrate adds what's missing
+		Version: 1,
+		Name:    "create accounts, imports and transactions",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&accountV1{}, &importV1{}, &transactionV1{})
+		},
+	},
+	{
+		Version: 2,
+		Name:    "store amounts in cents",
+		Up:      MigrateLegacyAmounts,
+	},
+	{
+		Version: 3,
+		Name:    "create usages",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&usageV3{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&usageV3{})
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create conversations and messages",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&conversationV4{}, &messageV4{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&messageV4{}, &conversationV4{})
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create ledger view",
+		Up: func(tx *gorm.DB) error {
+			return replaceLedgerView(tx, ledgerViewV5)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("DROP VIEW IF EXISTS " + LedgerView).Error
+		},
+	},
+	{
+		Version: 6,
+		Name:    "add merchants",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&merchantV6{}, &merchantRuleV6{}, &transactionV6{}); err != nil {
+				return err
+			}
+
+			if err := tx.Create(&defaultMerchantRulesV6).Error; err != nil {
+				return err
+			}
+
+			return replaceLedgerView(tx, ledgerViewV6)
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := replaceLedgerView(tx, ledgerViewV5); err != nil {
+				return err
+			}
+
+			for _, statement := range []string{
+				"DROP INDEX IF EXISTS idx_transactions_merchant_id",
+				"ALTER TABLE transactions DROP COLUMN merchant_id",
+			} {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Migrator().DropTable(&merchantRuleV6{}, &merchantV6{})
+		},
+	},
+	{
+		Version: 7,
+		Name:    "add categories",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&categoryV7{}, &categoryRuleV7{}, &transactionV7{}); err != nil {
+				return err
+			}
+
+			if err := tx.Create(&defaultCategoryRulesV7).Error; err != nil {
+				return err
+			}
+
+			return replaceLedgerView(tx, ledgerViewV7)
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := replaceLedgerView(tx, ledgerViewV6); err != nil {
+				return err
+			}
+
+			for _, statement := range []string{
+				"DROP INDEX IF EXISTS idx_transactions_category_id",
+				"ALTER TABLE transactions DROP COLUMN category_id",
+			} {
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Migrator().DropTable(&categoryRuleV7{}, &categoryV7{})
+		},
+	},
+}
+
+// The tables as each migration created them
+
+type accountV1 struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"uniqueIndex"`
+	Institution string
+	Kind        string
+	Number      string
+	CreatedAt   time.Time
+}
+
+func (accountV1) TableName() string { return "accounts" }
+
+type importV1 struct {
+	ID          uint `gorm:"primaryKey"`
+	Path        string
+	ContentHash string `gorm:"uniqueIndex"`
+	Parser      string
+	PeriodStart *time.Time
+	PeriodEnd   *time.Time
+	Inserted    int
+	Skipped     int
+	CreatedAt   time.Time
+}
+
+func (importV1) TableName() string { return "imports" }
+
+type transactionV1 struct {
+	ID          uint      `gorm:"primaryKey"`
+	Date        time.Time `gorm:"index"`
+	Description string
+	Type        string
+	Amount      int64  `gorm:"column:amount_cents"`
+	NetAmount   int64  `gorm:"column:net_amount_cents"`
+	Currency    string `gorm:"default:USD"`
+	SettleDate  time.Time
+	FITID       string `gorm:"column:fit_id;index"`
+	ImportID    *uint  `gorm:"index"`
+	AccountID   *uint  `gorm:"index"`
+	TransferID  *uint  `gorm:"index"`
+}
+
+func (transactionV1) TableName() string { return "transactions" }
+
+type usageV3 struct {
+	ID               uint      `gorm:"primaryKey"`
+	CreatedAt        time.Time `gorm:"index"`
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+	Priced           bool
+}
+
+func (usageV3) TableName() string { return "usages" }
+
+type conversationV4 struct {
+	ID        uint `gorm:"primaryKey"`
+	Title     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (conversationV4) TableName() string { return "conversations" }
+
+type messageV4 struct {
+	ID             uint `gorm:"primaryKey"`
+	ConversationID uint `gorm:"index"`
+	Position       int
+	Role           string
+	Content        string
+	Name           string
+	ToolCallID     string
+	ToolCalls      string
+	CreatedAt      time.Time
+}
+
+func (messageV4) TableName() string { return "messages" }
+
+type merchantV6 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"uniqueIndex"`
+	CreatedAt time.Time
+}
+
+func (merchantV6) TableName() string { return "merchants" }
+
+type merchantRuleV6 struct {
+	ID        uint `gorm:"primaryKey"`
+	Pattern   string
+	Merchant  string
+	Priority  int
+	CreatedAt time.Time
+}
+
+func (merchantRuleV6) TableName() string { return "merchant_rules" }
+
+type transactionV6 struct {
+	MerchantID *uint `gorm:"index"`
+}
+
+func (transactionV6) TableName() string { return "transactions" }
+
+// defaultMerchantRulesV6 cover merchants whose descriptions vary too much
+// for CleanDescription. Users can edit or delete them like their own.
+var defaultMerchantRulesV6 = []merchantRuleV6{
+	{Pattern: `UBER\s*\*?\s*EATS`, Merchant: "Uber Eats", Priority: 10},
+	{Pattern: `\bAMZN\b|AMAZON`, Merchant: "Amazon"},
+	{Pattern: `^UBER\b`, Merchant: "Uber"},
+	{Pattern: `\bLYFT\b`, Merchant: "Lyft"},
+	{Pattern: `DOORDASH`, Merchant: "DoorDash"},
+	{Pattern: `STARBUCKS`, Merchant: "Starbucks"},
+	{Pattern: `WAL-?MART|\bWM SUPERCENTER`, Merchant: "Walmart"},
+	{Pattern: `\bTARGET\b`, Merchant: "Target"},
+	{Pattern: `MCDONALD`, Merchant: "McDonald's"},
+	{Pattern: `NETFLIX`, Merchant: "Netflix"},
+	{Pattern: `SPOTIFY`, Merchant: "Spotify"},
+	{Pattern: `APPLE\.COM|^APPLE\b`, Merchant: "Apple"},
+	{Pattern: `GOOGLE`, Merchant: "Google"},
+	{Pattern: `COSTCO`, Merchant: "Costco"},
+}
+
+type categoryV7 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"uniqueIndex"`
+	CreatedAt time.Time
+}
+
+func (categoryV7) TableName() string { return "categories" }
+
+type categoryRuleV7 struct {
+	ID        uint `gorm:"primaryKey"`
+	Pattern   string
+	Type      string
+	MinAmount *int64 `gorm:"column:min_amount_cents"`
+	MaxAmount *int64 `gorm:"column:max_amount_cents"`
+	Category  string
+	Priority  int
+	CreatedAt time.Time
+}
+
+func (categoryRuleV7) TableName() string { return "category_rules" }
+
+type transactionV7 struct {
+	CategoryID *uint `gorm:"index"`
+}
+
+func (transactionV7) TableName() string { return "transactions" }
+
+// defaultCategoryRulesV7 answer the common questions, such as how much went
+// on dining, out of the box. The types Chime uses come first so transfers
+// and fees aren't taken for spending at a merchant; deposits no other rule
+// matches are income.
+var defaultCategoryRulesV7 = []categoryRuleV7{
+	{Type: "Transfer", Category: "Transfers", Priority: 20},
+	{Type: "Round Up", Category: "Transfers", Priority: 20},
+	{Type: "Fee", Category: "Fees", Priority: 20},
+	{Type: "ATM Withdrawal", Category: "Cash", Priority: 20},
+	{Pattern: `DOORDASH|UBER\s*\*?\s*EATS|GRUBHUB|STARBUCKS|MCDONALD|DUNKIN|CHIPOTLE|RESTAURANT|CAFE|COFFEE|PIZZA|BURGER|\bTACOS?\b|^TST\s*\*`, Category: "Dining", Priority: 10},
+	{Pattern: `GROCER|SAFEWAY|KROGER|WHOLEFDS|WHOLE FOODS|TRADER JOE|\bALDI\b|PUBLIX|SPROUTS|COSTCO|INSTACART`, Category: "Groceries"},
+	{Pattern: `^UBER\b|\bLYFT\b|\bSHELL\b|CHEVRON|EXXON|PARKING|TRANSIT`, Category: "Transportation"},
+	{Pattern: `NETFLIX|SPOTIFY|HULU|DISNEY|YOUTUBE|APPLE\.COM/BILL|PATREON`, Category: "Subscriptions"},
+	{Pattern: `COMCAST|XFINITY|VERIZON|AT&T|T-MOBILE|ELECTRIC|PG&E|\bWATER\b`, Category: "Utilities"},
+	{Pattern: `\bAMZN\b|AMAZON|WAL-?MART|\bTARGET\b|EBAY|ETSY`, Category: "Shopping"},
+	{Type: "Deposit", Category: "Income", Priority: -10},
+}
+
+const ledgerViewV5 = `CREATE VIEW ledger AS
+SELECT
+	t.id,
+	t.date,
+	t.description,
+	t.type,
+	t.amount_cents / 100.0     AS amount,
+	t.net_amount_cents / 100.0 AS net_amount,
+	t.currency,
+	t.settle_date,
+	a.name                     AS account,
+	t.transfer_id IS NOT NULL  AS internal_transfer,
+	t.transfer_id,
+	t.import_id
+FROM transactions t
+LEFT JOIN accounts a ON a.id = t.account_id`
+
+const ledgerViewV6 = `CREATE VIEW ledger AS
+SELECT
+	t.id,
+	t.date,
+	t.description,
+	m.name                     AS merchant,
+	t.type,
+	t.amount_cents / 100.0     AS amount,
+	t.net_amount_cents / 100.0 AS net_amount,
+	t.currency,
+	t.settle_date,
+	a.name                     AS account,
+	t.transfer_id IS NOT NULL  AS internal_transfer,
+	t.transfer_id,
+	t.import_id
+FROM transactions t
+LEFT JOIN accounts a ON a.id = t.account_id
+LEFT JOIN merchants m ON m.id = t.merchant_id`
+
+const ledgerViewV7 = `CREATE VIEW ledger AS
+SELECT
+	t.id,
+	t.date,
+	t.description,
+	m.name                     AS merchant,
+	c.name                     AS category,
+	t.type,
+	t.amount_cents / 100.0     AS amount,
+	t.net_amount_cents / 100.0 AS net_amount,
+	t.currency,
+	t.settle_date,
+	a.name                     AS account,
+	t.transfer_id IS NOT NULL  AS internal_transfer,
+	t.transfer_id,
+	t.import_id
+FROM transactions t
+LEFT JOIN accounts a ON a.id = t.account_id
+LEFT JOIN merchants m ON m.id = t.merchant_id
+LEFT JOIN categories c ON c.id = t.category_id`
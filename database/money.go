@@ -0,0 +1,129 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the ISO 4217 code assumed when a source doesn't say
+const DefaultCurrency = "USD"
+
+// Money is an exact amount in minor units, e.g. cents for USD. Amounts are
+// stored this way so sums across many rows don't drift and equality checks
+// are reliable.
+type Money int64
+
+// ParseMoney parses amounts such as "-$1,234.56", "1234.5", "+12" and the
+// accounting style "(12.00)". More than two decimal places is an error
+// unless the extra digits are zeros.
+func ParseMoney(s string) (Money, error) {
+	original := s
+	s = strings.TrimSpace(s)
+
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+
+	s = strings.NewReplacer("$", "", ",", "", " ", "").Replace(s)
+
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = !negative
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid amount %q", original)
+	}
+
+	if len(frac) > 2 {
+		if strings.Trim(frac[2:], "0") != "" {
+			return 0, fmt.Errorf("invalid amount %q: more than two decimal places", original)
+		}
+		frac = frac[:2]
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	if whole == "" {
+		whole = "0"
+	}
+
+	units, err := strconv.ParseUint(whole, 10, 63)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", original, err)
+	}
+
+	cents, err := strconv.ParseUint(frac, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", original, err)
+	}
+
+	m := Money(units*100 + cents)
+	if negative {
+		m = -m
+	}
+
+	return m, nil
+}
+
+// Float returns the amount in major units, e.g. dollars. Only use it for
+// display or for handing values to the model, never for arithmetic.
+func (m Money) Float() float64 {
+	return float64(m) / 100
+}
+
+// String formats the amount as a plain decimal, e.g. "-1234.56"
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, m/100, m%100)
+}
+
+// Format formats the amount for people, e.g. "-$1,234.56" for USD or
+// "1,234.56 EUR" for any other currency
+func (m Money) Format(currency string) string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+
+	whole := strconv.FormatInt(int64(m/100), 10)
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+
+	amount := fmt.Sprintf("%s.%02d", whole, m%100)
+
+	if currency == "" || currency == DefaultCurrency {
+		return sign + "$" + amount
+	}
+
+	return sign + amount + " " + currency
+}
+
+// MarshalJSON encodes the amount as a decimal number in major units
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON decodes a decimal number or string in major units
+func (m *Money) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseMoney(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
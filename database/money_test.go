@@ -0,0 +1,74 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := map[string]Money{
+		"-$274.18":   -27418,
+		"$1,234.56":  123456,
+		"(12.00)":    -1200,
+		"+42":        4200,
+		"0.1":        10,
+		"-4.500":     -450,
+		"$-3.05":     -305,
+		".99":        99,
+		"1000000.01": 100000001,
+	}
+
+	for in, want := range cases {
+		got, err := ParseMoney(in)
+		if err != nil {
+			t.Errorf("ParseMoney(%q) returned error: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestParseMoney_Invalid(t *testing.T) {
+	for _, in := range []string{"", "$", "abc", "1.234", "1.2.3"} {
+		if _, err := ParseMoney(in); err == nil {
+			t.Errorf("ParseMoney(%q) expected an error, got nil", in)
+		}
+	}
+}
+
+func TestMoney_Format(t *testing.T) {
+	cases := []struct {
+		money    Money
+		currency string
+		want     string
+	}{
+		{-27418, "USD", "-$274.18"},
+		{123456789, "", "$1,234,567.89"},
+		{5, "USD", "$0.05"},
+		{100000, "EUR", "1,000.00 EUR"},
+	}
+
+	for _, c := range cases {
+		if got := c.money.Format(c.currency); got != c.want {
+			t.Errorf("Money(%d).Format(%q) = %q, want %q", c.money, c.currency, got, c.want)
+		}
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(struct{ Amount Money }{-27418})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(data) != `{"Amount":-274.18}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+
+	var decoded struct{ Amount Money }
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Amount != -27418 {
+		t.Errorf("failed to round trip JSON: %v, %d", err, decoded.Amount)
+	}
+}
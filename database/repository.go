@@ -1,9 +1,15 @@
 package database
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// TransactionRepository queries the transactions table. The analytic methods
+// take a date range where from is inclusive, to is exclusive and a zero time
+// leaves that end open. Transfers between the user's own accounts are
+// neither income nor spending, so only DailyBalance counts them.
 type TransactionRepository struct {
 	db *gorm.DB
 }
@@ -17,3 +23,143 @@ func (r *TransactionRepository) ExecuteRawQuery(query string, args ...interface{
 	err := r.db.Raw(query, args...).Scan(&result).Error
 	return result, err
 }
+
+// SpendingByMonth totals the money spent per month, oldest first
+func (r *TransactionRepository) SpendingByMonth(from, to time.Time) ([]MonthlySpending, error) {
+	var months []MonthlySpending
+
+	err := r.external(from, to).
+		Select("substr(date, 1, 7) AS month, -sum(amount_cents) AS spent").
+		Where("amount_cents < 0").
+		Group("month").
+		Order("month").
+		Scan(&months).Error
+
+	return months, err
+}
+
+// merchantJoin and merchantName group transactions by merchant, or by
+// description for those without one
+const (
+	merchantJoin = "LEFT JOIN merchants ON merchants.id = transactions.merchant_id"
+	merchantName = "coalesce(merchants.name, transactions.description)"
+)
+
+// TopMerchants returns the n merchants the most money was spent at, most
+// first
+func (r *TransactionRepository) TopMerchants(n int, from, to time.Time) ([]DescriptionTotal, error) {
+	var totals []DescriptionTotal
+
+	err := r.external(from, to).
+		Joins(merchantJoin).
+		Select(merchantName + " AS description, -sum(amount_cents) AS total_spent").
+		Where("amount_cents < 0").
+		Group(merchantName).
+		Order("total_spent DESC, description").
+		Limit(n).
+		Scan(&totals).Error
+
+	return totals, err
+}
+
+// TransactionCountByMerchant counts the transactions of each merchant, most
+// first
+func (r *TransactionRepository) TransactionCountByMerchant(from, to time.Time) ([]DescriptionCount, error) {
+	var counts []DescriptionCount
+
+	err := r.external(from, to).
+		Joins(merchantJoin).
+		Select(merchantName + " AS description, count(*) AS total_transactions").
+		Group(merchantName).
+		Order("total_transactions DESC, description").
+		Scan(&counts).Error
+
+	return counts, err
+}
+
+// SpendingByCategory totals the money spent in each category, most first
+func (r *TransactionRepository) SpendingByCategory(from, to time.Time) ([]CategorySpending, error) {
+	var categories []CategorySpending
+
+	err := r.external(from, to).
+		Joins("LEFT JOIN categories ON categories.id = transactions.category_id").
+		Select(`coalesce(categories.name, '') AS category, count(*) AS transactions,
+			coalesce(-sum(CASE WHEN amount_cents < 0 THEN amount_cents END), 0) AS spent`).
+		Group("categories.name").
+		Order("spent DESC, category").
+		Scan(&categories).Error
+
+	return categories, err
+}
+
+// IncomeVsExpense totals the money that came in and went out per month,
+// oldest first
+func (r *TransactionRepository) IncomeVsExpense(from, to time.Time) ([]IncomeExpense, error) {
+	var months []IncomeExpense
+
+	err := r.external(from, to).
+		Select(`substr(date, 1, 7) AS month,
+			coalesce(sum(CASE WHEN amount_cents > 0 THEN amount_cents END), 0) AS income,
+			coalesce(-sum(CASE WHEN amount_cents < 0 THEN amount_cents END), 0) AS expense,
+			count(*) AS transactions`).
+		Group("month").
+		Order("month").
+		Scan(&months).Error
+
+	return months, err
+}
+
+// DailyBalance returns the running total of every transaction for each day
+// in the range that has any, oldest first. Statements don't carry a balance
+// per transaction, so the total starts from zero at the first transaction
+// rather than from the bank's opening balance; transactions before from
+// still count towards it.
+func (r *TransactionRepository) DailyBalance(from, to time.Time) ([]DailyBalance, error) {
+	var days []DailyBalance
+
+	query := r.db.Model(&Transaction{})
+	if !to.IsZero() {
+		query = query.Where("date < ?", to)
+	}
+
+	err := query.
+		Select("substr(date, 1, 10) AS date, sum(amount_cents) AS change").
+		Group("substr(date, 1, 10)").
+		Order("date").
+		Scan(&days).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		balance Money
+		first   = from.Format(time.DateOnly)
+		inRange []DailyBalance
+	)
+
+	for _, day := range days {
+		balance += day.Change
+		day.Balance = balance
+
+		if from.IsZero() || day.Date >= first {
+			inRange = append(inRange, day)
+		}
+	}
+
+	return inRange, nil
+}
+
+// external selects the transactions in the range other than transfers
+// between the user's own accounts
+func (r *TransactionRepository) external(from, to time.Time) *gorm.DB {
+	query := r.db.Model(&Transaction{}).Where("transfer_id IS NULL")
+
+	if !from.IsZero() {
+		query = query.Where("date >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("date < ?", to)
+	}
+
+	return query
+}
@@ -44,8 +44,8 @@ func TestExecuteRawQuery_NoArguments(t *testing.T) {
 
 	// Seed the database with some transactions
 	transactions := []Transaction{
-		{Date: time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC), Description: "Grocery Shopping", Type: "Purchase", Amount: 50.00, NetAmount: 50.00, SettleDate: time.Date(2023, 1, 11, 0, 0, 0, 0, time.UTC)},
-		{Date: time.Date(2023, 2, 15, 0, 0, 0, 0, time.UTC), Description: "Salary", Type: "Deposit", Amount: 1500.00, NetAmount: 1500.00, SettleDate: time.Date(2023, 2, 16, 0, 0, 0, 0, time.UTC)},
+		{Date: time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC), Description: "Grocery Shopping", Type: "Purchase", Amount: 5000, NetAmount: 5000, SettleDate: time.Date(2023, 1, 11, 0, 0, 0, 0, time.UTC)},
+		{Date: time.Date(2023, 2, 15, 0, 0, 0, 0, time.UTC), Description: "Salary", Type: "Deposit", Amount: 150000, NetAmount: 150000, SettleDate: time.Date(2023, 2, 16, 0, 0, 0, 0, time.UTC)},
 	}
 	if err := db.Create(&transactions).Error; err != nil {
 		t.Fatalf("failed to seed database: %v", err)
@@ -81,8 +81,8 @@ func TestExecuteRawQuery_LargeNumberOfArguments(t *testing.T) {
 
 	// Seed the database with transactions
 	transactions := []Transaction{
-		{Date: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Description: "Transaction 1", Type: "Purchase", Amount: 10.00, NetAmount: 10.00, SettleDate: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
-		{Date: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Description: "Transaction 2", Type: "Purchase", Amount: 20.00, NetAmount: 20.00, SettleDate: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{Date: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Description: "Transaction 1", Type: "Purchase", Amount: 1000, NetAmount: 1000, SettleDate: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{Date: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Description: "Transaction 2", Type: "Purchase", Amount: 2000, NetAmount: 2000, SettleDate: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)},
 		// Add more transactions as needed
 	}
 	if err := db.Create(&transactions).Error; err != nil {
@@ -143,8 +143,8 @@ func TestExecuteRawQuery_SpecialSQLKeywords(t *testing.T) {
 
 	// Seed the database with some transactions
 	transactions := []Transaction{
-		{Date: time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC), Description: "SELECT * FROM", Type: "Purchase", Amount: 50.00, NetAmount: 50.00, SettleDate: time.Date(2023, 1, 11, 0, 0, 0, 0, time.UTC)},
-		{Date: time.Date(2023, 2, 15, 0, 0, 0, 0, time.UTC), Description: "DROP TABLE", Type: "Deposit", Amount: 1500.00, NetAmount: 1500.00, SettleDate: time.Date(2023, 2, 16, 0, 0, 0, 0, time.UTC)},
+		{Date: time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC), Description: "SELECT * FROM", Type: "Purchase", Amount: 5000, NetAmount: 5000, SettleDate: time.Date(2023, 1, 11, 0, 0, 0, 0, time.UTC)},
+		{Date: time.Date(2023, 2, 15, 0, 0, 0, 0, time.UTC), Description: "DROP TABLE", Type: "Deposit", Amount: 150000, NetAmount: 150000, SettleDate: time.Date(2023, 2, 16, 0, 0, 0, 0, time.UTC)},
 	}
 	if err := db.Create(&transactions).Error; err != nil {
 		t.Fatalf("failed to seed database: %v", err)
@@ -213,8 +213,8 @@ func TestExecuteRawQuery_LargeResultSet(t *testing.T) {
 			Date:        time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i),
 			Description: "Transaction " + strconv.Itoa(i),
 			Type:        "Purchase",
-			Amount:      float64(i),
-			NetAmount:   float64(i),
+			Amount:      Money(i * 100),
+			NetAmount:   Money(i * 100),
 			SettleDate:  time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i),
 		})
 	}
@@ -345,3 +345,129 @@ func TestExecuteRawQuery_MixedDataTypes(t *testing.T) {
 		}
 	}
 }
+
+// newAnalyticsRepository seeds a paycheck, three purchases and a transfer to
+// savings across January and February 2024
+func newAnalyticsRepository(t *testing.T) *TransactionRepository {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Merchant{}, &Transaction{}); err != nil {
+		t.Fatalf("failed to migrate database schema: %v", err)
+	}
+
+	transferID := uint(99)
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	transactions := []Transaction{
+		{Date: day(1, 5), Description: "Payroll", Type: "Deposit", Amount: 200000},
+		{Date: day(1, 5), Description: "Coffee Shop", Type: "Purchase", Amount: -450},
+		{Date: day(1, 20), Description: "Grocery Store", Type: "Purchase", Amount: -12000},
+		{Date: day(1, 25), Description: "Transfer to Savings", Type: "Transfer", Amount: -50000, TransferID: &transferID},
+		{Date: day(2, 3), Description: "Coffee Shop", Type: "Purchase", Amount: -500},
+	}
+	if err := db.Create(&transactions).Error; err != nil {
+		t.Fatalf("failed to seed database: %v", err)
+	}
+
+	return NewTransactionRepository(db)
+}
+
+func TestSpendingByMonth(t *testing.T) {
+	repo := newAnalyticsRepository(t)
+
+	months, err := repo.SpendingByMonth(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []MonthlySpending{{Month: "2024-01", Spent: 12450}, {Month: "2024-02", Spent: 500}}
+	if !reflect.DeepEqual(months, expected) {
+		t.Errorf("expected %+v, got %+v", expected, months)
+	}
+}
+
+func TestTopMerchants(t *testing.T) {
+	repo := newAnalyticsRepository(t)
+
+	top, err := repo.TopMerchants(1, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(top) != 1 || top[0] != (DescriptionTotal{Description: "Grocery Store", TotalSpent: 12000}) {
+		t.Errorf("expected the grocery store, got %+v", top)
+	}
+
+	// February only
+	top, err = repo.TopMerchants(5, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []DescriptionTotal{{Description: "Coffee Shop", TotalSpent: 500}}
+	if !reflect.DeepEqual(top, expected) {
+		t.Errorf("expected %+v, got %+v", expected, top)
+	}
+}
+
+func TestTransactionCountByMerchant(t *testing.T) {
+	repo := newAnalyticsRepository(t)
+
+	counts, err := repo.TransactionCountByMerchant(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []DescriptionCount{
+		{Description: "Coffee Shop", TotalTransactions: 2},
+		{Description: "Grocery Store", TotalTransactions: 1},
+		{Description: "Payroll", TotalTransactions: 1},
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("expected %+v, got %+v", expected, counts)
+	}
+}
+
+func TestIncomeVsExpense(t *testing.T) {
+	repo := newAnalyticsRepository(t)
+
+	months, err := repo.IncomeVsExpense(time.Time{}, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []IncomeExpense{{Month: "2024-01", Income: 200000, Expense: 12450, Transactions: 3}}
+	if !reflect.DeepEqual(months, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, months)
+	}
+
+	if net := months[0].Net(); net != 187550 {
+		t.Errorf("expected a net of 187550, got %d", net)
+	}
+}
+
+func TestDailyBalance(t *testing.T) {
+	repo := newAnalyticsRepository(t)
+
+	// Days before the range still count towards the balance
+	days, err := repo.DailyBalance(time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []DailyBalance{
+		{Date: "2024-01-20", Change: -12000, Balance: 187550},
+		{Date: "2024-01-25", Change: -50000, Balance: 137550},
+		{Date: "2024-02-03", Change: -500, Balance: 137050},
+	}
+	if !reflect.DeepEqual(days, expected) {
+		t.Errorf("expected %+v, got %+v", expected, days)
+	}
+}
@@ -0,0 +1,254 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultQueryTimeout bounds how long a sandboxed query may run
+	DefaultQueryTimeout = 5 * time.Second
+
+	// DefaultMaxRows caps the number of rows a sandboxed query returns
+	DefaultMaxRows = 1000
+)
+
+// Query error codes
+const (
+	QueryErrorRejected = "rejected" // not a single read-only statement
+	QueryErrorTimeout  = "timeout"  // ran longer than the sandbox allows
+	QueryErrorSQL      = "sql"      // SQLite refused or failed the query
+)
+
+// QueryError explains why a sandboxed query didn't run. It marshals to JSON
+// so it can be handed back to the model that wrote the query.
+type QueryError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	SQL     string `json:"sql"`
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// QueryResult holds the rows of a sandboxed query in column order
+type QueryResult struct {
+	Columns []string
+	Rows    [][]interface{}
+
+	// Truncated is set when the query returned more than the row cap
+	Truncated bool
+}
+
+// Sandbox runs untrusted queries, such as the ones a language model writes.
+// Only a single SELECT or WITH statement is accepted, and it runs with a
+// timeout and a row cap. The sandbox should be given a connection from
+// OpenReadOnly so SQLite itself refuses writes if a statement slips through.
+type Sandbox struct {
+	db *gorm.DB
+
+	Timeout time.Duration
+	MaxRows int
+}
+
+func NewSandbox(db *gorm.DB) *Sandbox {
+	return &Sandbox{
+		db:      db,
+		Timeout: DefaultQueryTimeout,
+		MaxRows: DefaultMaxRows,
+	}
+}
+
+// forbiddenKeywords can't appear anywhere in a sandboxed query. WITH can
+// lead into INSERT, REPLACE, UPDATE or DELETE, so checking the first keyword
+// isn't enough. REPLACE is also a string function, so INTO stands in for it.
+var forbiddenKeywords = map[string]bool{
+	"ALTER":   true,
+	"ANALYZE": true,
+	"ATTACH":  true,
+	"CREATE":  true,
+	"DELETE":  true,
+	"DETACH":  true,
+	"DROP":    true,
+	"INSERT":  true,
+	"INTO":    true,
+	"PRAGMA":  true,
+	"REINDEX": true,
+	"UPDATE":  true,
+	"VACUUM":  true,
+}
+
+var keywordRegex = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// Query checks and runs query. Every failure is returned as a *QueryError.
+func (s *Sandbox) Query(ctx context.Context, query string) (*QueryResult, error) {
+	if err := checkQuery(query); err != nil {
+		return nil, err
+	}
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultQueryTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := s.run(ctx, query)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, &QueryError{
+				Code:    QueryErrorTimeout,
+				Message: fmt.Sprintf("the query ran longer than %s, narrow it down or aggregate", timeout),
+				SQL:     query,
+			}
+		}
+
+		return nil, &QueryError{Code: QueryErrorSQL, Message: err.Error(), SQL: query}
+	}
+
+	return result, nil
+}
+
+func (s *Sandbox) run(ctx context.Context, query string) (*QueryResult, error) {
+	maxRows := s.MaxRows
+	if maxRows <= 0 {
+		maxRows = DefaultMaxRows
+	}
+
+	rows, err := s.db.WithContext(ctx).Raw(query).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &QueryResult{}
+	if result.Columns, err = rows.Columns(); err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		if len(result.Rows) == maxRows {
+			result.Truncated = true
+			break
+		}
+
+		values := make([]interface{}, len(result.Columns))
+		pointers := make([]interface{}, len(values))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		// SQLite hands text back as []byte, which would marshal as base64
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+
+		result.Rows = append(result.Rows, values)
+	}
+
+	return result, rows.Err()
+}
+
+// checkQuery accepts a single SELECT or WITH statement that contains none of
+// the forbidden keywords outside of string literals, quoted identifiers and
+// comments
+func checkQuery(query string) error {
+	reject := func(format string, args ...interface{}) error {
+		return &QueryError{Code: QueryErrorRejected, Message: fmt.Sprintf(format, args...), SQL: query}
+	}
+
+	code, err := stripLiterals(query)
+	if err != nil {
+		return reject("%v", err)
+	}
+
+	code = strings.TrimRight(strings.TrimSpace(code), "; \t\r\n")
+	if code == "" {
+		return reject("the query is empty")
+	}
+
+	if strings.Contains(code, ";") {
+		return reject("only a single statement is allowed")
+	}
+
+	keywords := keywordRegex.FindAllString(code, -1)
+	if len(keywords) == 0 {
+		return reject("only SELECT and WITH statements are allowed")
+	}
+
+	if first := strings.ToUpper(keywords[0]); first != "SELECT" && first != "WITH" {
+		return reject("only SELECT and WITH statements are allowed, got %s", first)
+	}
+
+	for _, keyword := range keywords {
+		if upper := strings.ToUpper(keyword); forbiddenKeywords[upper] {
+			return reject("%s is not allowed", upper)
+		}
+	}
+
+	return nil
+}
+
+// stripLiterals blanks out string literals, quoted identifiers and comments
+// so that only the SQL keywords, names and operators remain
+func stripLiterals(query string) (string, error) {
+	var code strings.Builder
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		var (
+			open = 1
+			end  string
+		)
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end = string(c)
+		case c == '[':
+			end = "]"
+		case strings.HasPrefix(query[i:], "--"):
+			open, end = 2, "\n"
+		case strings.HasPrefix(query[i:], "/*"):
+			open, end = 2, "*/"
+		default:
+			code.WriteByte(c)
+			continue
+		}
+
+		closing := strings.Index(query[i+open:], end)
+		if closing < 0 {
+			if end == "\n" {
+				break
+			}
+			return "", fmt.Errorf("unterminated %s", query[i:i+open])
+		}
+
+		// Literals and identifiers keep a placeholder so "a'b'c" doesn't
+		// turn into a single word, comments become whitespace
+		if end == "\n" || end == "*/" {
+			code.WriteByte(' ')
+		} else {
+			code.WriteString(" _ ")
+		}
+
+		// Doubled quotes inside a literal are consumed as two adjacent
+		// literals, which is equivalent for our purposes
+		i += open + closing + len(end) - 1
+	}
+
+	return code.String(), nil
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newSandboxDB creates a database file with a few transactions and returns a
+// read-only connection to it
+func newSandboxDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "transactions.db")
+
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Transaction{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		if err := db.Create(&Transaction{
+			Date:        time.Date(2024, 7, i, 0, 0, 0, 0, time.UTC),
+			Description: "Coffee",
+			Type:        "Purchase",
+			Amount:      Money(-i * 100),
+			NetAmount:   Money(-i * 100),
+		}).Error; err != nil {
+			t.Fatalf("failed to seed database: %v", err)
+		}
+	}
+
+	sqlDB, _ := db.DB()
+	_ = sqlDB.Close()
+
+	readOnly, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("failed to open database read-only: %v", err)
+	}
+
+	t.Cleanup(func() {
+		sqlDB, _ := readOnly.DB()
+		_ = sqlDB.Close()
+	})
+
+	return readOnly
+}
+
+func TestSandboxQuery(t *testing.T) {
+	sandbox := NewSandbox(newSandboxDB(t))
+
+	result, err := sandbox.Query(context.Background(), "SELECT description, amount_cents FROM transactions ORDER BY id;")
+	if err != nil {
+		t.Fatalf("Query returned an error: %v", err)
+	}
+
+	if len(result.Columns) != 2 || result.Columns[0] != "description" || result.Columns[1] != "amount_cents" {
+		t.Errorf("unexpected columns %v", result.Columns)
+	}
+
+	if len(result.Rows) != 5 || result.Truncated {
+		t.Fatalf("expected 5 rows without truncation, got %d (truncated %v)", len(result.Rows), result.Truncated)
+	}
+
+	if result.Rows[0][0] != "Coffee" || result.Rows[0][1] != int64(-100) {
+		t.Errorf("unexpected first row %v", result.Rows[0])
+	}
+}
+
+func TestSandboxRowCap(t *testing.T) {
+	sandbox := NewSandbox(newSandboxDB(t))
+	sandbox.MaxRows = 3
+
+	result, err := sandbox.Query(context.Background(), "SELECT * FROM transactions")
+	if err != nil {
+		t.Fatalf("Query returned an error: %v", err)
+	}
+
+	if len(result.Rows) != 3 || !result.Truncated {
+		t.Errorf("expected 3 rows with truncation, got %d (truncated %v)", len(result.Rows), result.Truncated)
+	}
+}
+
+func TestSandboxChecks(t *testing.T) {
+	sandbox := NewSandbox(newSandboxDB(t))
+
+	tests := []struct {
+		query string
+		code  string
+	}{
+		{"SELECT 'DROP TABLE transactions' AS s", ""},
+		{"SELECT 1 -- then DROP TABLE transactions", ""},
+		{"/* totals */ WITH t AS (SELECT 1 AS x) SELECT x FROM t", ""},
+		{"SELECT replace(description, 'Coffee', 'Tea') FROM transactions", ""},
+		{`SELECT "update" FROM (SELECT 1 AS "update")`, ""},
+		{"", QueryErrorRejected},
+		{";", QueryErrorRejected},
+		{"DROP TABLE transactions", QueryErrorRejected},
+		{"SELECT 1; DROP TABLE transactions", QueryErrorRejected},
+		{"PRAGMA table_info(transactions)", QueryErrorRejected},
+		{"ATTACH DATABASE 'other.db' AS other", QueryErrorRejected},
+		{"SELECT * FROM pragma_table_info('transactions') WHERE 1 = (SELECT 1 FROM (PRAGMA x))", QueryErrorRejected},
+		{"WITH doomed AS (SELECT id FROM transactions) DELETE FROM transactions", QueryErrorRejected},
+		{"WITH t AS (SELECT 1) REPLACE INTO transactions (id) VALUES (1)", QueryErrorRejected},
+		{"SELECT 'unterminated", QueryErrorRejected},
+		{"SELECT no_such_column FROM transactions", QueryErrorSQL},
+	}
+
+	for _, tt := range tests {
+		_, err := sandbox.Query(context.Background(), tt.query)
+
+		if tt.code == "" {
+			if err != nil {
+				t.Errorf("%q: unexpected error %v", tt.query, err)
+			}
+			continue
+		}
+
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) {
+			t.Errorf("%q: expected a *QueryError, got %v", tt.query, err)
+			continue
+		}
+
+		if queryErr.Code != tt.code || queryErr.SQL != tt.query {
+			t.Errorf("%q: unexpected error %+v", tt.query, queryErr)
+		}
+	}
+}
+
+func TestSandboxTimeout(t *testing.T) {
+	sandbox := NewSandbox(newSandboxDB(t))
+	sandbox.Timeout = 50 * time.Millisecond
+
+	_, err := sandbox.Query(context.Background(),
+		"WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c")
+
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) || queryErr.Code != QueryErrorTimeout {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+}
+
+func TestOpenReadOnlyRefusesWrites(t *testing.T) {
+	db := newSandboxDB(t)
+
+	if err := db.Exec("DELETE FROM transactions").Error; err == nil {
+		t.Fatal("expected the read-only connection to refuse a DELETE")
+	}
+}
@@ -8,17 +8,82 @@ type Transaction struct {
 	Date        time.Time `gorm:"index"`
 	Description string
 	Type        string
-	Amount      float64
-	NetAmount   float64
+	Amount      Money  `gorm:"column:amount_cents"`
+	NetAmount   Money  `gorm:"column:net_amount_cents"`
+	Currency    string `gorm:"default:USD"`
 	SettleDate  time.Time
+	FITID       string `gorm:"column:fit_id;index"` // Financial institution ID from OFX imports
+	ImportID    *uint  `gorm:"index"`               // Import that created the row
+	AccountID   *uint  `gorm:"index"`               // Account the statement belongs to
+	TransferID  *uint  `gorm:"index"`               // Other side of a transfer between the user's own accounts
+	MerchantID  *uint  `gorm:"index"`               // Canonical merchant, see AssignMerchants
+	CategoryID  *uint  `gorm:"index"`               // Spending category, see AssignCategories
 }
 
+// Import records a single processed source file so every transaction can be
+// traced back to the statement it came from, and so files that were already
+// imported can be skipped
+type Import struct {
+	ID          uint   `gorm:"primaryKey"`
+	Path        string // Original source file, e.g. the PDF rather than its converted text
+	ContentHash string `gorm:"uniqueIndex"` // SHA-256 of the source file
+	Parser      string
+	PeriodStart *time.Time
+	PeriodEnd   *time.Time
+	Inserted    int
+	Skipped     int
+	CreatedAt   time.Time
+}
+
+// DescriptionTotal is the money spent at one merchant, as a positive amount.
+// Description is the merchant's name, or the raw description of
+// transactions without a merchant.
 type DescriptionTotal struct {
 	Description string
-	TotalSpent  float64 `json:"total_spent"`
+	TotalSpent  Money `json:"total_spent"`
 }
 
+// DescriptionCount is the number of transactions with one merchant, named
+// like in DescriptionTotal
 type DescriptionCount struct {
 	Description       string
 	TotalTransactions int `json:"total_transactions"`
 }
+
+// CategorySpending is the number of transactions in a category and the money
+// spent on it, as a positive amount. Category is empty for transactions
+// without one.
+type CategorySpending struct {
+	Category     string `json:"category"`
+	Transactions int    `json:"transactions"`
+	Spent        Money  `json:"spent"`
+}
+
+// MonthlySpending is the money spent in a calendar month, as a positive
+// amount
+type MonthlySpending struct {
+	Month string `json:"month"` // YYYY-MM
+	Spent Money  `json:"spent"`
+}
+
+// IncomeExpense is the money that came in and went out in a calendar month,
+// both as positive amounts
+type IncomeExpense struct {
+	Month        string `json:"month"` // YYYY-MM
+	Income       Money  `json:"income"`
+	Expense      Money  `json:"expense"`
+	Transactions int    `json:"transactions"`
+}
+
+// Net is what was left over, negative when more went out than came in
+func (i IncomeExpense) Net() Money {
+	return i.Income - i.Expense
+}
+
+// DailyBalance is the sum of every transaction up to and including a day,
+// and the part of it that came from that day
+type DailyBalance struct {
+	Date    string `json:"date"` // YYYY-MM-DD
+	Change  Money  `json:"change"`
+	Balance Money  `json:"balance"`
+}
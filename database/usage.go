@@ -0,0 +1,46 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Usage records the tokens and estimated cost of one call to the chat model
+type Usage struct {
+	ID               uint      `gorm:"primaryKey"`
+	CreatedAt        time.Time `gorm:"index"`
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64 // Estimated, in dollars
+	Priced           bool    // False when the model had no price, so Cost is zero
+}
+
+// MonthlyUsage adds up the usage of one calendar month
+type MonthlyUsage struct {
+	Month            string  `json:"month"` // YYYY-MM
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// UsageByMonth totals the recorded usage per month, oldest first
+func UsageByMonth(db *gorm.DB) ([]MonthlyUsage, error) {
+	var months []MonthlyUsage
+
+	// created_at is stored as "YYYY-MM-DD HH:MM:SS...", the month is its
+	// first seven characters
+	err := db.Model(&Usage{}).
+		Select(`substr(created_at, 1, 7) AS month,
+			count(*) AS calls,
+			sum(prompt_tokens) AS prompt_tokens,
+			sum(completion_tokens) AS completion_tokens,
+			sum(cost) AS cost`).
+		Group("month").
+		Order("month").
+		Scan(&months).Error
+
+	return months, err
+}
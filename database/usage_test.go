@@ -0,0 +1,53 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestUsageByMonth(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&Usage{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	for _, usage := range []Usage{
+		{CreatedAt: time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC), Model: "gpt-4o", PromptTokens: 1000, CompletionTokens: 100, Cost: 0.0035, Priced: true},
+		{CreatedAt: time.Date(2024, 7, 31, 23, 0, 0, 0, time.UTC), Model: "gpt-4o-mini", PromptTokens: 2000, CompletionTokens: 200, Cost: 0.00042, Priced: true},
+		{CreatedAt: time.Date(2024, 8, 2, 12, 0, 0, 0, time.UTC), Model: "llama3.1", PromptTokens: 500, CompletionTokens: 50},
+	} {
+		if err := db.Create(&usage).Error; err != nil {
+			t.Fatalf("failed to seed database: %v", err)
+		}
+	}
+
+	months, err := UsageByMonth(db)
+	if err != nil {
+		t.Fatalf("UsageByMonth returned an error: %v", err)
+	}
+
+	if len(months) != 2 {
+		t.Fatalf("expected 2 months, got %+v", months)
+	}
+
+	july := months[0]
+	if july.Month != "2024-07" || july.Calls != 2 || july.PromptTokens != 3000 || july.CompletionTokens != 300 {
+		t.Errorf("unexpected July totals %+v", july)
+	}
+
+	if math.Abs(july.Cost-0.00392) > 1e-9 {
+		t.Errorf("expected a July cost of 0.00392, got %v", july.Cost)
+	}
+
+	if months[1].Month != "2024-08" || months[1].Calls != 1 || months[1].Cost != 0 {
+		t.Errorf("unexpected August totals %+v", months[1])
+	}
+}
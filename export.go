@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kmesiab/chime-ai/config"
+	"github.com/kmesiab/chime-ai/database"
+)
+
+// exportCommand writes the ledger to a file or stdout
+func exportCommand(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("chime-ai export", flag.ExitOnError)
+	cfg.DatabaseFlags(fs)
+
+	var period dateRange
+	period.flags(fs)
+	format := fs.String("format", "csv", "Output format, csv or json")
+	output := fs.String("o", "", "File to write to instead of stdout")
+	_ = fs.Parse(args)
+
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown export format %q", *format)
+	}
+
+	db, err := database.OpenReadOnly(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer closeDB(db)
+
+	if err := database.CheckVersion(db); err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return writeExport(out, db, *format, period)
+}
+
+// writeExport writes every column of the ledger rows in period, oldest
+// first, as CSV with a header or as a JSON array of objects
+func writeExport(out io.Writer, db *gorm.DB, format string, period dateRange) error {
+	rows, err := period.apply(db.Table(database.LedgerView)).Order("date, id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	var (
+		csvWriter *csv.Writer
+		records   = []map[string]interface{}{}
+	)
+
+	if format == "csv" {
+		csvWriter = csv.NewWriter(out)
+		if err := csvWriter.Write(columns); err != nil {
+			return err
+		}
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+
+		if csvWriter != nil {
+			record := make([]string, len(columns))
+			for i, value := range values {
+				record[i] = exportString(value)
+			}
+			if err := csvWriter.Write(record); err != nil {
+				return err
+			}
+			continue
+		}
+
+		record := map[string]interface{}{}
+		for i, value := range values {
+			record[columns[i]] = exportValue(value)
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+		return csvWriter.Error()
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(records)
+}
+
+// exportValue converts a value read from SQLite to one that encodes well
+func exportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.DateOnly)
+	default:
+		return v
+	}
+}
+
+// exportString formats a value for a CSV field, NULL as an empty field
+func exportString(value interface{}) string {
+	switch v := exportValue(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
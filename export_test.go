@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteExport_CSV(t *testing.T) {
+	db := newLedgerDB(t)
+
+	var period dateRange
+	if err := period.To.Set("2024-07-03"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := writeExport(&out, db, "csv", period); err != nil {
+		t.Fatalf("writeExport returned an error: %v", err)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v", err)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("expected a header and 2 rows, got %d records", len(records))
+	}
+
+	row := map[string]string{}
+	for i, column := range records[0] {
+		row[column] = records[2][i]
+	}
+
+	if row["date"] != "2024-07-03" || row["description"] != "Coffee Shop" || row["amount"] != "-4.5" {
+		t.Errorf("unexpected row: %v", row)
+	}
+
+	if row["account"] != "" {
+		t.Errorf("expected an empty account, got %q", row["account"])
+	}
+}
+
+func TestWriteExport_JSON(t *testing.T) {
+	db := newLedgerDB(t)
+
+	var out bytes.Buffer
+	if err := writeExport(&out, db, "json", dateRange{}); err != nil {
+		t.Fatalf("writeExport returned an error: %v", err)
+	}
+
+	var records []map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &records); err != nil {
+		t.Fatalf("export is not valid JSON: %v", err)
+	}
+
+	if len(records) != 5 {
+		t.Fatalf("expected 5 records, got %d", len(records))
+	}
+
+	last := records[4]
+	if last["date"] != "2024-08-02" || last["amount"] != -5.0 || last["account"] != nil {
+		t.Errorf("unexpected record: %v", last)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+var (
+	// conversation is the saved session memory belongs to, nil until the
+	// first question is answered
+	conversation *database.Conversation
+
+	// saved is the number of messages of memory already in the database
+	saved int
+)
+
+// saveConversation writes the messages added to memory since the last save,
+// creating the conversation on the first one
+func saveConversation() error {
+	if appDB == nil {
+		return nil
+	}
+
+	if conversation == nil {
+		conversation = &database.Conversation{Title: conversationTitle()}
+		if err := appDB.Create(conversation).Error; err != nil {
+			conversation = nil
+			return err
+		}
+
+		// The system prompt isn't saved, the current one is used on resume
+		saved = 0
+		if len(memory) > 0 && memory[0].Role == openai.ChatMessageRoleSystem {
+			saved = 1
+		}
+	}
+
+	var messages []database.Message
+	for _, message := range memory[saved:] {
+		stored, err := storeMessage(message)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, stored)
+	}
+
+	if err := database.AppendMessages(appDB, conversation.ID, messages); err != nil {
+		return err
+	}
+
+	saved = len(memory)
+
+	return nil
+}
+
+// conversationTitle is the first question, shortened
+func conversationTitle() string {
+	for _, message := range memory {
+		if message.Role == openai.ChatMessageRoleUser {
+			title := []rune(strings.Join(strings.Fields(message.Content), " "))
+			if len(title) > 60 {
+				title = append(title[:57], []rune("...")...)
+			}
+			return string(title)
+		}
+	}
+
+	return "Untitled"
+}
+
+// newConversation starts over without deleting the saved conversation
+func newConversation() {
+	resetMemory()
+	conversation = nil
+	saved = 0
+
+	if tracker != nil {
+		tracker.Reset()
+	}
+}
+
+// resumeConversation replaces memory with a saved conversation
+func resumeConversation(id uint) error {
+	if appDB == nil {
+		return fmt.Errorf("history isn't being saved")
+	}
+
+	loaded, stored, err := database.LoadConversation(appDB, id)
+	if err != nil {
+		return err
+	}
+
+	messages := make([]openai.ChatCompletionMessage, 0, len(stored))
+	for _, message := range stored {
+		restored, err := restoreMessage(message)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, restored)
+	}
+
+	newConversation()
+	memory = append(memory, messages...)
+	conversation = loaded
+	saved = len(memory)
+
+	return nil
+}
+
+// deleteConversation removes a saved conversation, starting a new one if it
+// was the current one
+func deleteConversation(id uint) error {
+	if appDB == nil {
+		return fmt.Errorf("history isn't being saved")
+	}
+
+	if err := database.DeleteConversation(appDB, id); err != nil {
+		return err
+	}
+
+	if conversation != nil && conversation.ID == id {
+		newConversation()
+	}
+
+	return nil
+}
+
+// writeConversations lists the saved conversations, marking the current one
+func writeConversations(out io.Writer) {
+	if appDB == nil {
+		fmt.Fprintln(out, "History isn't being saved.")
+		return
+	}
+
+	conversations, err := database.ListConversations(appDB)
+	if err != nil {
+		fmt.Fprintf(out, "Error listing conversations: %v\n", err)
+		return
+	}
+
+	if len(conversations) == 0 {
+		fmt.Fprintln(out, "No saved conversations.")
+		return
+	}
+
+	for _, c := range conversations {
+		marker := " "
+		if conversation != nil && conversation.ID == c.ID {
+			marker = "*"
+		}
+
+		fmt.Fprintf(out, "%s %4d  %s  %3d messages  %s\n",
+			marker, c.ID, c.UpdatedAt.Local().Format("2006-01-02 15:04"), c.Messages, c.Title)
+	}
+}
+
+// parseConversationID reads the ID argument of /resume and /delete
+func parseConversationID(arg string) (uint, error) {
+	id, err := strconv.ParseUint(arg, 10, 0)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("expected a conversation ID from /sessions, got %q", arg)
+	}
+
+	return uint(id), nil
+}
+
+func storeMessage(message openai.ChatCompletionMessage) (database.Message, error) {
+	stored := database.Message{
+		Role:       message.Role,
+		Content:    message.Content,
+		Name:       message.Name,
+		ToolCallID: message.ToolCallID,
+	}
+
+	if len(message.ToolCalls) > 0 {
+		toolCalls, err := json.Marshal(message.ToolCalls)
+		if err != nil {
+			return stored, err
+		}
+		stored.ToolCalls = string(toolCalls)
+	}
+
+	return stored, nil
+}
+
+func restoreMessage(stored database.Message) (openai.ChatCompletionMessage, error) {
+	message := openai.ChatCompletionMessage{
+		Role:       stored.Role,
+		Content:    stored.Content,
+		Name:       stored.Name,
+		ToolCallID: stored.ToolCallID,
+	}
+
+	if stored.ToolCalls != "" {
+		if err := json.Unmarshal([]byte(stored.ToolCalls), &message.ToolCalls); err != nil {
+			return message, fmt.Errorf("invalid tool calls in message %d: %w", stored.ID, err)
+		}
+	}
+
+	return message, nil
+}
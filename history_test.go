@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/kmesiab/chime-ai/config"
+	"github.com/kmesiab/chime-ai/database"
+)
+
+func TestConversationRoundTrip(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&database.Conversation{}, &database.Message{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	appDB = db
+	t.Cleanup(func() {
+		appDB = nil
+		newConversation()
+	})
+
+	memory = []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: config.DefaultSystemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: "How much did I spend on coffee?"},
+		{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{{
+			ID:       "call_1",
+			Type:     openai.ToolTypeFunction,
+			Function: openai.FunctionCall{Name: "TransactionsTool", Arguments: `{"sql":"SELECT sum(amount) FROM ledger"}`},
+		}}},
+		{Role: openai.ChatMessageRoleTool, Name: "TransactionsTool", ToolCallID: "call_1", Content: "sum(amount)\n-42.5\n"},
+		{Role: openai.ChatMessageRoleAssistant, Content: "$42.50"},
+	}
+
+	if err := saveConversation(); err != nil {
+		t.Fatalf("saveConversation returned an error: %v", err)
+	}
+
+	// A second turn is appended to the same conversation
+	memory = append(memory,
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "And tea?"},
+		openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "Nothing."},
+	)
+
+	if err := saveConversation(); err != nil {
+		t.Fatalf("saveConversation returned an error: %v", err)
+	}
+
+	id := conversation.ID
+	want := append([]openai.ChatCompletionMessage(nil), memory...)
+
+	newConversation()
+	if len(memory) != 1 || conversation != nil {
+		t.Fatalf("expected a fresh conversation, got %d messages", len(memory))
+	}
+
+	var out bytes.Buffer
+	runChatCommand(&out, "/sessions")
+	if !strings.Contains(out.String(), "6 messages  How much did I spend on coffee?") {
+		t.Errorf("unexpected session list:\n%s", out.String())
+	}
+
+	if err := resumeConversation(id); err != nil {
+		t.Fatalf("resumeConversation returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(memory, want) {
+		t.Errorf("resumed memory differs:\n got %+v\nwant %+v", memory, want)
+	}
+
+	if err := deleteConversation(id); err != nil {
+		t.Fatalf("deleteConversation returned an error: %v", err)
+	}
+
+	if conversation != nil || len(memory) != 1 {
+		t.Error("expected deleting the current conversation to start a new one")
+	}
+
+	if err := resumeConversation(id); err == nil {
+		t.Error("expected an error resuming a deleted conversation")
+	}
+}
@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+func TestChimeParser_DetectsAccount(t *testing.T) {
+	text := "Savings Account Statement\nChime\n" +
+		"7/19/2024   Transfer from Chime Checking Account     Transfer    $275.00    $275.00      7/19/2024\n"
+
+	statement, err := chimeParser{}.Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if statement.Account == nil || statement.Account.Name != "Chime Savings" {
+		t.Errorf("expected Chime Savings, got %+v", statement.Account)
+	}
+}
+
+func TestChimeParser_IgnoresTransferDescriptions(t *testing.T) {
+	text := "Chime\nTransfer to Chime Savings Account\n"
+
+	statement, err := chimeParser{}.Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if statement.Account != nil {
+		t.Errorf("expected no account, got %+v", statement.Account)
+	}
+}
+
+func TestChimeAccountFromFilename(t *testing.T) {
+	cases := map[string]string{
+		"Jane_Doe_Savings_eStatement (3).pdf":        "Chime Savings",
+		"Jane_Doe_Credit_Builder_eStatement (1).pdf": "Chime Credit Builder",
+		"Jane_Doe_Checking_eStatement (2).pdf":       "Chime Checking",
+		"statement.txt":                              "Chime Checking",
+	}
+
+	for name, want := range cases {
+		if got := chimeAccountFromFilename(name); got.Name != want {
+			t.Errorf("chimeAccountFromFilename(%q) = %q, want %q", name, got.Name, want)
+		}
+	}
+}
+
+func TestOFXParser_DetectsAccount(t *testing.T) {
+	statement, err := ofxParser{}.Parse(strings.NewReader(strings.Replace(sgmlOFX, "<STMTRS>",
+		"<STMTRS>\n<CURDEF>USD\n<BANKACCTFROM>\n<BANKID>123\n<ACCTID>000123456789\n<ACCTTYPE>SAVINGS\n</BANKACCTFROM>", 1)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	account := statement.Account
+	if account == nil || account.Kind != database.AccountSavings || account.Number != "6789" {
+		t.Errorf("unexpected account: %+v", account)
+	}
+}
+
+func TestProcessFile_SameTransactionInTwoAccounts(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := initDB(filepath.Join(dir, "transactions.db"))
+	if err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+
+	line := "7/19/2024   Transfer from Chime Checking Account     Transfer    $275.00    $275.00      7/19/2024\n"
+	files := map[string]string{
+		"savings.txt":       "Savings Account Statement\n" + line,
+		"credit.txt":        "Credit Builder Account Statement\n" + line,
+		"savings-again.txt": "Savings Account Statement\n" + line + "\n",
+	}
+
+	for name, text := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+			t.Fatalf("failed to write statement: %v", err)
+		}
+		processFile(path, path, "", "", db)
+	}
+
+	var count int64
+	db.Model(&database.Transaction{}).Count(&count)
+	if count != 2 {
+		t.Errorf("expected one transaction per account, got %d", count)
+	}
+
+	var accounts int64
+	db.Model(&database.Account{}).Count(&accounts)
+	if accounts != 2 {
+		t.Errorf("expected 2 accounts, got %d", accounts)
+	}
+}
+
+func TestOFXParser_RefusesSeveralAccounts(t *testing.T) {
+	account := func(number, kind string) string {
+		return "<STMTRS>\n<BANKACCTFROM>\n<BANKID>123\n<ACCTID>" + number + "\n<ACCTTYPE>" + kind + "\n</BANKACCTFROM>"
+	}
+
+	checking := strings.Replace(sgmlOFX, "<STMTRS>", account("1111", "CHECKING"), 1)
+	savings := strings.Replace(sgmlOFX, "<STMTRS>", account("2222", "SAVINGS"), 1)
+
+	// Both statements in one download
+	start, end := strings.Index(savings, "<STMTTRNRS>"), strings.Index(savings, "</BANKMSGSRSV1>")
+	text := strings.Replace(checking, "</BANKMSGSRSV1>", savings[start:end]+"</BANKMSGSRSV1>", 1)
+
+	if _, err := (ofxParser{}).Parse(strings.NewReader(text)); err == nil {
+		t.Fatal("expected an error for a file with statements for two accounts")
+	}
+
+	// Two statements for the same account are fine
+	text = strings.Replace(checking, "</BANKMSGSRSV1>", checking[start:end]+"</BANKMSGSRSV1>", 1)
+	if _, err := (ofxParser{}).Parse(strings.NewReader(text)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
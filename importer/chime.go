@@ -0,0 +1,223 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+func init() {
+	RegisterParser(chimeParser{})
+}
+
+// chimeLineRegex matches a single transaction line in a Chime checking
+// statement converted with `pdftotext -layout`
+var chimeLineRegex = regexp.MustCompile(`^(\d{1,2}/\d{1,2}/\d{4})\s+(.*?)\s+(Transfer|Purchase|Direct Debit|ATM Withdrawal|Fee|Deposit|Round Up)\s+(-?\$[\d,]+\.\d{2})\s+(-?\$[\d,]+\.\d{2})\s+(\d{1,2}/\d{1,2}/\d{4})$`)
+
+// chimeCandidateRegex matches lines that start like a transaction, used to
+// catch lines chimeLineRegex misses
+var chimeCandidateRegex = regexp.MustCompile(`^\d{1,2}/\d{1,2}/\d{4}\s+.*\$\d`)
+
+// chimePeriodRegex matches the statement period, e.g.
+// "Statement period: 7/01/2024 - 7/31/2024" or "July 1, 2024 to July 31, 2024"
+var chimePeriodRegex = regexp.MustCompile(`(?i)statement period:?\s+(.+?)\s+(?:-|–|to|through)\s+(.+?)\s*$`)
+
+// chimeBalanceRegex matches the summary lines, e.g.
+// "Beginning balance on 7/01/2024      $1,234.56"
+var chimeBalanceRegex = regexp.MustCompile(`(?i)^\s*(beginning|opening|starting|ending|closing) balance(?:\s+(?:on|as of))?\s*([A-Za-z]+ \d{1,2}, \d{4}|\d{1,2}/\d{1,2}/\d{4})?\s+(-?\$[\d,]+\.\d{2})\s*$`)
+
+// chimeParser parses Chime checking account statements
+type chimeParser struct{}
+
+func (chimeParser) Name() string {
+	return "chime"
+}
+
+// Detect recognizes a Chime statement either by name or, failing that, by
+// finding a transaction line in the header. Lines with commas or tags are
+// ignored when looking for the name so Chime CSV and OFX exports aren't
+// mistaken for statement text.
+func (chimeParser) Detect(header []string) bool {
+	for _, line := range header {
+		named := strings.Contains(strings.ToLower(line), "chime") && !strings.ContainsAny(line, ",<")
+		if named || chimeLineRegex.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (chimeParser) Parse(r io.Reader) (*Statement, error) {
+	statement := &Statement{}
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+
+		match := chimeLineRegex.FindStringSubmatch(line)
+		if match == nil {
+			if statement.Account == nil && len(statement.Transactions) == 0 {
+				statement.Account = chimeAccountFromHeader(line)
+			}
+
+			if chimeCandidateRegex.MatchString(line) {
+				statement.reject(lineNumber, line, "unrecognized transaction line")
+				continue
+			}
+
+			parseChimeSummary(statement, line)
+			continue
+		}
+
+		amount, err := database.ParseMoney(match[4])
+		if err != nil {
+			statement.reject(lineNumber, line, err.Error())
+			continue
+		}
+
+		netAmount, err := database.ParseMoney(match[5])
+		if err != nil {
+			statement.reject(lineNumber, line, err.Error())
+			continue
+		}
+
+		date, err := time.Parse("1/02/2006", match[1])
+		if err != nil {
+			statement.reject(lineNumber, line, fmt.Sprintf("invalid date: %v", err))
+			continue
+		}
+
+		settleDate, err := time.Parse("1/02/2006", match[6])
+		if err != nil {
+			statement.reject(lineNumber, line, fmt.Sprintf("invalid settlement date: %v", err))
+			continue
+		}
+
+		statement.Transactions = append(statement.Transactions, database.Transaction{
+			Date:        date,
+			Description: strings.TrimSpace(match[2]),
+			Type:        match[3],
+			Amount:      amount,
+			NetAmount:   netAmount,
+			Currency:    database.DefaultCurrency,
+			SettleDate:  settleDate,
+		})
+	}
+
+	return statement, scanner.Err()
+}
+
+// Chime accounts, by the names used on statements and in file names
+var (
+	chimeChecking      = database.Account{Name: "Chime Checking", Institution: "Chime", Kind: database.AccountChecking}
+	chimeSavings       = database.Account{Name: "Chime Savings", Institution: "Chime", Kind: database.AccountSavings}
+	chimeCreditBuilder = database.Account{Name: "Chime Credit Builder", Institution: "Chime", Kind: database.AccountCredit}
+)
+
+// chimeAccountFromHeader identifies the account from a statement title such
+// as "Savings Account Statement". Transfer descriptions like "Transfer from
+// Chime Savings Account" name the other account, so they are ignored.
+func chimeAccountFromHeader(line string) *database.Account {
+	lower := strings.ToLower(line)
+	if strings.Contains(lower, "transfer") || strings.Contains(lower, "round up") {
+		return nil
+	}
+
+	return chimeAccountFromText(lower, "account", "statement")
+}
+
+// chimeAccountFromFilename identifies the account from a downloaded file
+// name such as "Your_Name_Savings_eStatement (1).pdf", defaulting to
+// checking, the only account older versions of the importer understood
+func chimeAccountFromFilename(path string) *database.Account {
+	lower := strings.NewReplacer("_", " ", "-", " ").Replace(strings.ToLower(filepath.Base(path)))
+
+	if account := chimeAccountFromText(lower, "estatement", "statement"); account != nil {
+		return account
+	}
+
+	account := chimeChecking
+	return &account
+}
+
+// chimeAccountFromText returns the Chime account named in text, which must
+// also contain one of the given context words
+func chimeAccountFromText(lower string, context ...string) *database.Account {
+	hasContext := false
+	for _, word := range context {
+		if strings.Contains(lower, word) {
+			hasContext = true
+			break
+		}
+	}
+
+	if !hasContext {
+		return nil
+	}
+
+	var account database.Account
+	switch {
+	case strings.Contains(lower, "credit builder"):
+		account = chimeCreditBuilder
+	case strings.Contains(lower, "savings"):
+		account = chimeSavings
+	case strings.Contains(lower, "checking"):
+		account = chimeChecking
+	default:
+		return nil
+	}
+
+	return &account
+}
+
+// parseChimeSummary records the statement period and balances from the
+// summary section. Only the first occurrence of each is kept since later
+// pages may repeat them for other accounts.
+func parseChimeSummary(statement *Statement, line string) {
+	if match := chimePeriodRegex.FindStringSubmatch(line); match != nil && statement.PeriodStart.IsZero() {
+		start, startErr := parseStatementDate(match[1])
+		end, endErr := parseStatementDate(match[2])
+		if startErr == nil && endErr == nil {
+			statement.PeriodStart, statement.PeriodEnd = start, end
+		}
+		return
+	}
+
+	match := chimeBalanceRegex.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+
+	balance, err := parseBalance(match[3])
+	if err != nil {
+		log.Printf("Error parsing balance %q: %v", match[3], err)
+		return
+	}
+
+	date, _ := parseStatementDate(match[2])
+
+	switch strings.ToLower(match[1]) {
+	case "beginning", "opening", "starting":
+		if statement.OpeningBalance == nil {
+			statement.OpeningBalance = balance
+			if statement.PeriodStart.IsZero() {
+				statement.PeriodStart = date
+			}
+		}
+	default:
+		if statement.ClosingBalance == nil {
+			statement.ClosingBalance = balance
+			if statement.PeriodEnd.IsZero() {
+				statement.PeriodEnd = date
+			}
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+// Amount sign conventions supported by CSV profiles
+const (
+	// SignAsIs keeps amounts as exported: debits negative, credits positive
+	SignAsIs = "as-is"
+
+	// SignInverted flips amounts for exports that show debits as positive
+	// numbers, as most credit card exports do
+	SignInverted = "inverted"
+
+	// SignDebitCredit reads debits and credits from two separate columns
+	SignDebitCredit = "debit-credit"
+)
+
+// CSVProfile maps the columns of a bank's CSV export onto a Transaction.
+// Column names are matched case-insensitively against the header row.
+type CSVProfile struct {
+	Name string `json:"name"`
+
+	DateColumn        string `json:"date_column"`
+	DateFormat        string `json:"date_format"` // Go time layout, e.g. "01/02/2006"
+	DescriptionColumn string `json:"description_column"`
+	TypeColumn        string `json:"type_column,omitempty"`
+
+	AmountSign      string `json:"amount_sign"`
+	AmountColumn    string `json:"amount_column,omitempty"`
+	DebitColumn     string `json:"debit_column,omitempty"`
+	CreditColumn    string `json:"credit_column,omitempty"`
+	NetAmountColumn string `json:"net_amount_column,omitempty"`
+
+	SettleDateColumn string `json:"settle_date_column,omitempty"`
+
+	// Currency is the ISO 4217 code of every amount in the export, USD if empty
+	Currency string `json:"currency,omitempty"`
+
+	// Account is the name of the account the export belongs to, if known
+	Account string `json:"account,omitempty"`
+}
+
+// builtinCSVProfiles are registered as parsers at startup
+var builtinCSVProfiles = []CSVProfile{
+	{
+		Name:              "chime",
+		DateColumn:        "Transaction Date",
+		DateFormat:        "01/02/2006",
+		DescriptionColumn: "Description",
+		TypeColumn:        "Type",
+		AmountSign:        SignAsIs,
+		AmountColumn:      "Amount",
+		NetAmountColumn:   "Net Amount",
+		SettleDateColumn:  "Settlement Date",
+	},
+	{
+		Name:              "generic",
+		DateColumn:        "Date",
+		DateFormat:        "2006-01-02",
+		DescriptionColumn: "Description",
+		AmountSign:        SignAsIs,
+		AmountColumn:      "Amount",
+	},
+}
+
+func init() {
+	for _, profile := range builtinCSVProfiles {
+		RegisterParser(csvParser{profile: profile})
+	}
+}
+
+// loadCSVProfiles reads a JSON array of CSV profiles from path and
+// registers each one as a statement parser. Nothing is registered if any
+// profile is invalid or its name is already taken.
+func loadCSVProfiles(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read CSV profiles: %w", err)
+	}
+
+	var profiles []CSVProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return fmt.Errorf("failed to parse CSV profiles: %w", err)
+	}
+
+	names := map[string]bool{}
+	for _, profile := range profiles {
+		if err := profile.validate(); err != nil {
+			return fmt.Errorf("invalid CSV profile %q: %w", profile.Name, err)
+		}
+
+		if names[profile.Name] {
+			return fmt.Errorf("CSV profile %q is defined more than once", profile.Name)
+		}
+		names[profile.Name] = true
+
+		if _, err := lookupParser(csvParser{profile: profile}.Name()); err == nil {
+			return fmt.Errorf("CSV profile %q clashes with a built-in parser", profile.Name)
+		}
+	}
+
+	for _, profile := range profiles {
+		RegisterParser(csvParser{profile: profile})
+	}
+
+	return nil
+}
+
+// validate checks that the profile maps every column it needs
+func (p CSVProfile) validate() error {
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("name is required")
+	case p.DateColumn == "" || p.DateFormat == "":
+		return fmt.Errorf("date_column and date_format are required")
+	case p.DescriptionColumn == "":
+		return fmt.Errorf("description_column is required")
+	}
+
+	switch p.AmountSign {
+	case SignAsIs, SignInverted:
+		if p.AmountColumn == "" {
+			return fmt.Errorf("amount_column is required for amount_sign %q", p.AmountSign)
+		}
+	case SignDebitCredit:
+		if p.DebitColumn == "" || p.CreditColumn == "" {
+			return fmt.Errorf("debit_column and credit_column are required for amount_sign %q", p.AmountSign)
+		}
+	default:
+		return fmt.Errorf("unknown amount_sign %q", p.AmountSign)
+	}
+
+	return nil
+}
+
+// columns returns every column name the profile reads from
+func (p CSVProfile) columns() []string {
+	var columns []string
+	for _, c := range []string{
+		p.DateColumn, p.DescriptionColumn, p.TypeColumn, p.AmountColumn,
+		p.DebitColumn, p.CreditColumn, p.NetAmountColumn, p.SettleDateColumn,
+	} {
+		if c != "" {
+			columns = append(columns, c)
+		}
+	}
+
+	return columns
+}
+
+// csvParser is a StatementParser for one CSV profile
+type csvParser struct {
+	profile CSVProfile
+}
+
+func (p csvParser) Name() string {
+	return "csv-" + p.profile.Name
+}
+
+// Detect matches when the first line is a CSV header containing every
+// column the profile uses
+func (p csvParser) Detect(header []string) bool {
+	if len(header) == 0 {
+		return false
+	}
+
+	record, err := csv.NewReader(strings.NewReader(header[0])).Read()
+	if err != nil {
+		return false
+	}
+
+	_, err = p.indexColumns(record)
+	return err == nil
+}
+
+func (p csvParser) Parse(r io.Reader) (*Statement, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	index, err := p.indexColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := index[strings.ToLower(column)]
+		if column == "" || !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	statement := &Statement{}
+	if p.profile.Account != "" {
+		statement.Account = &database.Account{Name: p.profile.Account, Kind: database.AccountOther}
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV line %d: %w", line, err)
+		}
+
+		transaction, err := p.toTransaction(func(column string) string { return field(record, column) })
+		if err != nil {
+			statement.reject(line, strings.Join(record, ","), err.Error())
+			continue
+		}
+
+		statement.Transactions = append(statement.Transactions, transaction)
+	}
+
+	return statement, nil
+}
+
+// indexColumns maps lower-cased column names to their position in header,
+// failing if any column used by the profile is missing
+func (p csvParser) indexColumns(header []string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+
+	for _, column := range p.profile.columns() {
+		if _, ok := index[strings.ToLower(column)]; !ok {
+			return nil, fmt.Errorf("CSV header is missing column %q", column)
+		}
+	}
+
+	return index, nil
+}
+
+// toTransaction builds a transaction from a single CSV record
+func (p csvParser) toTransaction(field func(column string) string) (database.Transaction, error) {
+	profile := p.profile
+
+	date, err := time.Parse(profile.DateFormat, field(profile.DateColumn))
+	if err != nil {
+		return database.Transaction{}, fmt.Errorf("invalid date: %w", err)
+	}
+
+	settleDate := date
+	if profile.SettleDateColumn != "" && field(profile.SettleDateColumn) != "" {
+		if settleDate, err = time.Parse(profile.DateFormat, field(profile.SettleDateColumn)); err != nil {
+			return database.Transaction{}, fmt.Errorf("invalid settlement date: %w", err)
+		}
+	}
+
+	var amount database.Money
+	switch profile.AmountSign {
+	case SignDebitCredit:
+		// Only one of the two columns is filled in, the other is zero
+		debitField, creditField := field(profile.DebitColumn), field(profile.CreditColumn)
+		if strings.TrimSpace(debitField) == "" && strings.TrimSpace(creditField) == "" {
+			return database.Transaction{}, fmt.Errorf("missing amount")
+		}
+
+		var debit, credit database.Money
+		if strings.TrimSpace(debitField) != "" {
+			if debit, err = parseCSVAmount(debitField); err != nil {
+				return database.Transaction{}, fmt.Errorf("invalid debit: %w", err)
+			}
+		}
+		if strings.TrimSpace(creditField) != "" {
+			if credit, err = parseCSVAmount(creditField); err != nil {
+				return database.Transaction{}, fmt.Errorf("invalid credit: %w", err)
+			}
+		}
+		amount = credit - abs(debit)
+	default:
+		if amount, err = parseCSVAmount(field(profile.AmountColumn)); err != nil {
+			return database.Transaction{}, fmt.Errorf("invalid amount: %w", err)
+		}
+		if profile.AmountSign == SignInverted {
+			amount = -amount
+		}
+	}
+
+	netAmount := amount
+	if profile.NetAmountColumn != "" && field(profile.NetAmountColumn) != "" {
+		if netAmount, err = parseCSVAmount(field(profile.NetAmountColumn)); err != nil {
+			return database.Transaction{}, fmt.Errorf("invalid net amount: %w", err)
+		}
+		if profile.AmountSign == SignInverted {
+			netAmount = -netAmount
+		}
+	}
+
+	currency := profile.Currency
+	if currency == "" {
+		currency = database.DefaultCurrency
+	}
+
+	transactionType := field(profile.TypeColumn)
+	if transactionType == "" {
+		transactionType = "Purchase"
+		if amount > 0 {
+			transactionType = "Deposit"
+		}
+	}
+
+	return database.Transaction{
+		Date:        date,
+		Description: field(profile.DescriptionColumn),
+		Type:        transactionType,
+		Amount:      amount,
+		NetAmount:   netAmount,
+		Currency:    currency,
+		SettleDate:  settleDate,
+	}, nil
+}
+
+// parseCSVAmount parses amounts such as "-$1,234.56", "1234.56" and the
+// accounting style "(12.00)". An empty string is an error rather than zero.
+func parseCSVAmount(s string) (database.Money, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, fmt.Errorf("missing amount")
+	}
+
+	return database.ParseMoney(s)
+}
+
+func abs(m database.Money) database.Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
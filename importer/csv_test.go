@@ -0,0 +1,164 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+func TestCSVParser_ChimeExport(t *testing.T) {
+	export := `Transaction Date,Description,Type,Amount,Net Amount,Settlement Date
+07/19/2024,"Supermaven, Inc.",Purchase,-$10.00,-$10.00,07/20/2024
+07/19/2024,Transfer from Chime Savings Account,Transfer,$275.00,$275.00,07/19/2024
+`
+
+	p, err := detectParser(statementHeader(export))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Name() != "csv-chime" {
+		t.Fatalf("expected csv-chime parser, got %s", p.Name())
+	}
+
+	statement, err := p.Parse(strings.NewReader(export))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transactions := statement.Transactions
+
+	if len(transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(transactions))
+	}
+
+	if transactions[0].Description != "Supermaven, Inc." || transactions[0].Amount != -1000 {
+		t.Errorf("unexpected transaction: %+v", transactions[0])
+	}
+}
+
+func TestCSVParser_DebitCreditColumns(t *testing.T) {
+	p := csvParser{profile: CSVProfile{
+		Name:              "bank",
+		DateColumn:        "Posted",
+		DateFormat:        "2006-01-02",
+		DescriptionColumn: "Memo",
+		AmountSign:        SignDebitCredit,
+		DebitColumn:       "Debit",
+		CreditColumn:      "Credit",
+	}}
+
+	export := `Posted,Memo,Debit,Credit
+2024-03-01,Coffee,4.50,
+2024-03-02,Payroll,,"1,200.00"
+2024-03-03,Nothing,,
+`
+
+	statement, err := p.Parse(strings.NewReader(export))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transactions := statement.Transactions
+
+	if len(transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(transactions))
+	}
+
+	if transactions[0].Amount != -450 || transactions[0].Type != "Purchase" {
+		t.Errorf("unexpected debit: %+v", transactions[0])
+	}
+
+	if transactions[1].Amount != 120000 || transactions[1].Type != "Deposit" {
+		t.Errorf("unexpected credit: %+v", transactions[1])
+	}
+
+	if !transactions[1].SettleDate.Equal(transactions[1].Date) {
+		t.Errorf("expected settlement date to default to the transaction date")
+	}
+
+	if len(statement.Rejected) != 1 || statement.Rejected[0].Line != 4 || statement.Rejected[0].Reason != "missing amount" {
+		t.Errorf("expected the line without a debit or credit to be rejected, got %+v", statement.Rejected)
+	}
+}
+
+func TestCSVParser_MissingAmount(t *testing.T) {
+	p := csvParser{profile: CSVProfile{
+		Name:              "bank",
+		DateColumn:        "Date",
+		DateFormat:        "2006-01-02",
+		DescriptionColumn: "Description",
+		AmountColumn:      "Amount",
+	}}
+
+	export := `Date,Description,Amount
+2024-07-01,Coffee,
+2024-07-02,Lunch,-12.00
+`
+
+	statement, err := p.Parse(strings.NewReader(export))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(statement.Transactions) != 1 || statement.Transactions[0].Amount != -1200 {
+		t.Errorf("expected only the lunch to be read, got %+v", statement.Transactions)
+	}
+
+	if len(statement.Rejected) != 1 || !strings.Contains(statement.Rejected[0].Reason, "missing amount") {
+		t.Errorf("expected the coffee to be rejected for a missing amount, got %+v", statement.Rejected)
+	}
+}
+
+func TestParseCSVAmount(t *testing.T) {
+	cases := map[string]database.Money{
+		"-$1,234.56": -123456,
+		"(12.00)":    -1200,
+		"42":         4200,
+	}
+
+	for in, want := range cases {
+		got, err := parseCSVAmount(in)
+		if err != nil {
+			t.Errorf("parseCSVAmount(%q) returned error: %v", in, err)
+		}
+		if got != want {
+			t.Errorf("parseCSVAmount(%q) = %v, want %v", in, got, want)
+		}
+	}
+
+	if _, err := parseCSVAmount(" "); err == nil {
+		t.Error("expected an error for a blank amount")
+	}
+}
+
+func TestLoadCSVProfiles_NameClash(t *testing.T) {
+	profile := func(name string) string {
+		return `{"name": "` + name + `", "date_column": "Date", "date_format": "2006-01-02", ` +
+			`"description_column": "Description", "amount_sign": "as-is", "amount_column": "Amount"}`
+	}
+
+	for _, test := range []struct {
+		profiles string
+		want     string
+	}{
+		{"[" + profile("clash-test") + ", " + profile("generic") + "]", "clashes with a built-in parser"},
+		{"[" + profile("clash-test") + ", " + profile("clash-test") + "]", "defined more than once"},
+	} {
+		path := filepath.Join(t.TempDir(), "profiles.json")
+		if err := os.WriteFile(path, []byte(test.profiles), 0o600); err != nil {
+			t.Fatalf("failed to write profiles: %v", err)
+		}
+
+		if err := loadCSVProfiles(path); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("expected an error containing %q, got %v", test.want, err)
+		}
+
+		if _, err := lookupParser("csv-clash-test"); err == nil {
+			t.Errorf("expected no profile to be registered when one is refused")
+		}
+	}
+}
@@ -0,0 +1,548 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kmesiab/chime-ai/config"
+	"github.com/kmesiab/chime-ai/database"
+)
+
+// Run imports the statements in the configured directory, or with
+// -list-imports or -undo, manages earlier imports. args are the flags after
+// "chime-ai import".
+func Run(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("chime-ai import", flag.ExitOnError)
+
+	// Directory, parser and account settings come from the config and can be
+	// overridden by flags
+	cfg.ImportFlags(fs)
+	fs.Lookup("parser").Usage += " (" + strings.Join(Parsers(), ", ") + ")"
+
+	listHistory := fs.Bool("list-imports", false, "List previously imported files and exit")
+	undo := fs.String("undo", "", "Remove every transaction created by an import, given the source file or its content hash, and exit")
+	dryRun := fs.Bool("dry-run", false, "Report what would be imported without changing the database or deleting text files")
+	jsonReport := fs.Bool("json", false, "Print the import report as JSON")
+	_ = fs.Parse(args)
+
+	if cfg.Import.CSVProfiles != "" {
+		if err := loadCSVProfiles(cfg.Import.CSVProfiles); err != nil {
+			return fmt.Errorf("failed to load CSV profiles: %w", err)
+		}
+	}
+
+	var (
+		db  *gorm.DB
+		err error
+	)
+
+	if *dryRun {
+		var cleanup func()
+		if db, cleanup, err = initScratchDB(cfg.Database); err != nil {
+			return fmt.Errorf("failed to initialize dry run database: %w", err)
+		}
+		defer cleanup()
+
+		log.Println("Dry run: changes are made to a scratch copy of the database and discarded")
+	} else if db, err = initDB(cfg.Database); err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	if *listHistory {
+		if err := listImports(db, os.Stdout); err != nil {
+			return fmt.Errorf("failed to list imports: %w", err)
+		}
+		return nil
+	}
+
+	if *undo != "" {
+		record, err := resolveImport(db, *undo)
+		if err != nil {
+			return fmt.Errorf("failed to find import: %w", err)
+		}
+
+		removed, err := undoImport(db, record)
+		if err != nil {
+			return fmt.Errorf("failed to undo import #%d: %w", record.ID, err)
+		}
+
+		log.Printf("Removed import #%d (%s) and its %d transactions", record.ID, record.Path, removed)
+		return nil
+	}
+
+	if cfg.Import.PDFBackend != PDFBackendBuiltin && cfg.Import.PDFBackend != PDFBackendPdftotext {
+		return fmt.Errorf("unknown PDF backend %q", cfg.Import.PDFBackend)
+	}
+
+	if cfg.Import.PDFBackend == PDFBackendPdftotext && !isCommandAvailable("pdftotext") {
+		return fmt.Errorf("pdftotext not found, install poppler or use -pdf-backend %s", PDFBackendBuiltin)
+	}
+
+	log.Printf("Converting PDFs to text with the %s backend...", cfg.Import.PDFBackend)
+	sources := convertPDFsToText(cfg.Import.Dir, cfg.Import.PDFBackend, db)
+
+	txtFiles, err := filepath.Glob(filepath.Join(cfg.Import.Dir, "*.txt"))
+	if err != nil {
+		return fmt.Errorf("failed to list text files: %w", err)
+	}
+
+	exportFiles, err := globFiles(cfg.Import.Dir, "*.csv", "*.ofx", "*.qfx")
+	if err != nil {
+		return fmt.Errorf("failed to list export files: %w", err)
+	}
+
+	files := append(txtFiles, exportFiles...)
+	if len(files) == 0 {
+		log.Println("No text, CSV or OFX files found for processing.")
+		return nil
+	}
+
+	log.Printf("Found %d text and %d export files for processing.", len(txtFiles), len(exportFiles))
+
+	report := newImportReport(*dryRun, processFilesConcurrently(files, sources, cfg.Import.Parser, cfg.Import.Account, db))
+
+	if report.TransfersPaired, err = database.PairTransfers(db); err != nil {
+		log.Printf("Failed to pair transfers between accounts: %v", err)
+	} else if report.TransfersPaired > 0 {
+		log.Printf("Paired %d transfers between accounts", report.TransfersPaired)
+	}
+
+	if assigned, err := database.AssignMerchants(db, true); err != nil {
+		log.Printf("Failed to assign merchants: %v", err)
+	} else if assigned > 0 {
+		log.Printf("Assigned merchants to %d transactions", assigned)
+	}
+
+	if categorized, err := database.AssignCategories(db, true); err != nil {
+		log.Printf("Failed to categorize transactions: %v", err)
+	} else if categorized > 0 {
+		log.Printf("Categorized %d transactions", categorized)
+	}
+
+	switch {
+	case *jsonReport:
+		if err := report.WriteJSON(os.Stdout); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	case *dryRun:
+		report.WriteText(os.Stdout)
+	}
+
+	if *dryRun {
+		log.Println("Dry run complete, the database and text files were left untouched.")
+		return nil
+	}
+
+	// CSV and OFX files are the original exports, so only the generated text files are removed
+	cleanupTxtFiles(txtFiles)
+	log.Println("All files processed and cleaned up successfully!")
+
+	return nil
+}
+
+// initDB opens the database and brings its schema up to date
+func initDB(dbPath string) (*gorm.DB, error) {
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := database.Migrate(db); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// initScratchDB initializes a temporary copy of the database at dbPath, or
+// an empty one if it doesn't exist yet, so a dry run can go through the
+// exact same steps as a real import without touching the real database.
+// The returned function removes the copy.
+func initScratchDB(dbPath string) (*gorm.DB, func(), error) {
+	dir, err := os.MkdirTemp("", "chime-ai-dry-run")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	scratchPath := filepath.Join(dir, filepath.Base(dbPath))
+
+	data, err := os.ReadFile(dbPath)
+	switch {
+	case err == nil:
+		err = os.WriteFile(scratchPath, data, 0o600)
+	case errors.Is(err, fs.ErrNotExist):
+		err = nil
+	}
+
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to copy database: %w", err)
+	}
+
+	db, err := initDB(scratchPath)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	return db, func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+		cleanup()
+	}, nil
+}
+
+// globFiles lists the files in dir matching any of the patterns. Patterns are
+// matched in both lower and upper case since bank exports use either.
+func globFiles(dir string, patterns ...string) ([]string, error) {
+	seen := map[string]bool{}
+	var files []string
+
+	for _, pattern := range patterns {
+		for _, p := range []string{strings.ToLower(pattern), strings.ToUpper(pattern)} {
+			matches, err := filepath.Glob(filepath.Join(dir, p))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, match := range matches {
+				if !seen[match] {
+					seen[match] = true
+					files = append(files, match)
+				}
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// isCommandAvailable checks if a command is available in the system
+func isCommandAvailable(cmd string) bool {
+	_, err := exec.LookPath(cmd)
+	return err == nil
+}
+
+// convertPDFsToText converts all PDFs in the given directory to text files
+// using the given extraction backend. PDFs that were already imported are
+// skipped. It returns the source PDF of each text file it wrote.
+func convertPDFsToText(dir, backend string, db *gorm.DB) map[string]string {
+	sources := map[string]string{}
+
+	files, err := globFiles(dir, "*.pdf")
+	if err != nil {
+		log.Printf("Failed to list PDF files: %v", err)
+		return sources
+	}
+
+	for _, pdfFile := range files {
+		if _, imported, err := checkImported(db, pdfFile); err != nil {
+			log.Printf("Failed to check import history for %s: %v", pdfFile, err)
+			continue
+		} else if imported {
+			continue
+		}
+
+		baseName := strings.TrimSuffix(filepath.Base(pdfFile), filepath.Ext(pdfFile))
+		txtFile := filepath.Join(dir, baseName+".txt")
+
+		text, err := extractPDFText(backend, pdfFile)
+		if err != nil {
+			log.Printf("Failed to convert %s to text: %v", pdfFile, err)
+			continue
+		}
+
+		if err := os.WriteFile(txtFile, []byte(text), 0o600); err != nil {
+			log.Printf("Failed to write %s: %v", txtFile, err)
+		} else {
+			log.Printf("Converted %s to %s", pdfFile, txtFile)
+			sources[txtFile] = pdfFile
+		}
+	}
+
+	return sources
+}
+
+// checkImported hashes path and reports whether a file with the same
+// contents has been imported before
+func checkImported(db *gorm.DB, path string) (string, bool, error) {
+	contentHash, err := hashFile(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	existing, err := findImport(db, contentHash)
+	if err != nil || existing == nil {
+		return contentHash, false, err
+	}
+
+	log.Printf("Already imported %s on %s as import #%d, skipping", path, existing.CreatedAt.Format(time.DateTime), existing.ID)
+	return contentHash, true, nil
+}
+
+// importWrites serializes the transaction each file's import is written in.
+// SQLite has a single writer, and files that read the database and then
+// write to it at the same time would fail with "database is locked".
+var importWrites sync.Mutex
+
+// processFilesConcurrently processes multiple files in parallel and returns
+// a report for each, in the order given. Files are read and parsed
+// concurrently but written one at a time. sources maps text files converted
+// from PDFs back to the PDF they came from.
+func processFilesConcurrently(filenames []string, sources map[string]string, parserName, accountName string, db *gorm.DB) []*FileReport {
+	var wg sync.WaitGroup
+	reports := make([]*FileReport, len(filenames))
+
+	for i, filename := range filenames {
+		source, ok := sources[filename]
+		if !ok {
+			source = filename
+		}
+
+		wg.Add(1)
+		go func(i int, f, source string) {
+			defer wg.Done()
+			reports[i] = processFile(f, source, parserName, accountName, db)
+		}(i, filename, source)
+	}
+
+	wg.Wait()
+
+	return reports
+}
+
+// processFile parses a single statement file and inserts data into the database.
+// The parser is chosen by sniffing the file header unless parserName is set.
+// The import is recorded against source, the file the statement originally
+// came from, and skipped entirely if source was imported before. Transactions
+// are assigned to accountName if set, otherwise to the detected account.
+func processFile(filename, source, parserName, accountName string, db *gorm.DB) *FileReport {
+	report := &FileReport{File: filename, Source: source}
+
+	fail := func(status, format string, args ...any) *FileReport {
+		report.Status = status
+		report.Error = fmt.Sprintf(format, args...)
+		log.Printf("Skipping file %s: %s", filename, report.Error)
+		return report
+	}
+
+	contentHash, imported, err := checkImported(db, source)
+	if err != nil {
+		return fail(StatusFailed, "failed to check import history: %v", err)
+	} else if imported {
+		report.Status = StatusAlreadyImported
+		return report
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fail(StatusFailed, "error opening file: %v", err)
+	}
+
+	var parser StatementParser
+	if parserName != "" {
+		parser, err = lookupParser(parserName)
+	} else {
+		parser, err = detectParser(statementHeader(string(content)))
+	}
+
+	if err != nil {
+		return fail(StatusUnrecognized, "%v", err)
+	}
+
+	report.Parser = parser.Name()
+	log.Printf("Processing file: %s (parser: %s)", filename, parser.Name())
+
+	statement, err := parser.Parse(bytes.NewReader(content))
+	if err != nil {
+		return fail(StatusFailed, "error reading file: %v", err)
+	}
+
+	report.Period = statement.Period()
+	report.Reconciliation = reportReconciliation(filename, statement)
+	report.Rejected = statement.Rejected
+
+	for _, rejection := range statement.Rejected {
+		log.Printf("Rejected line %d in %s: %s: %q", rejection.Line, filename, rejection.Reason, rejection.Text)
+	}
+
+	account := statementAccount(statement, parser.Name(), source, accountName)
+	if account != nil {
+		report.Account = account.Name
+	}
+
+	record := newImport(source, contentHash, parser.Name(), statement)
+
+	importWrites.Lock()
+	defer importWrites.Unlock()
+
+	var existing *database.Import
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+
+		// A file with the same contents may have been written since the
+		// check above, e.g. a statement downloaded twice
+		var err error
+		if existing, err = findImport(tx, contentHash); err != nil || existing != nil {
+			return err
+		}
+
+		if account != nil {
+			if err := database.FindOrCreateAccount(tx, account); err != nil {
+				return err
+			}
+
+			for i := range statement.Transactions {
+				statement.Transactions[i].AccountID = &account.ID
+			}
+		}
+
+		var transactions []database.Transaction
+		for _, transaction := range statement.Transactions {
+			duplicate, err := isDuplicate(tx, transaction)
+			if err != nil {
+				return err
+			}
+
+			if duplicate {
+				log.Printf("Duplicate transaction found, skipping: %+v", transaction)
+				report.Duplicates = append(report.Duplicates, transaction)
+				continue
+			}
+
+			transactions = append(transactions, transaction)
+		}
+
+		record.Inserted = len(transactions)
+		record.Skipped = len(report.Duplicates)
+		if err := tx.Create(record).Error; err != nil {
+			return fmt.Errorf("failed to record import: %w", err)
+		}
+
+		if len(transactions) == 0 {
+			return nil
+		}
+
+		for i := range transactions {
+			transactions[i].ImportID = &record.ID
+		}
+
+		if err := tx.Create(&transactions).Error; err != nil {
+			return err
+		}
+
+		report.Inserted = transactions
+		return nil
+	})
+
+	if err != nil {
+		report.Inserted = nil
+		return fail(StatusFailed, "error inserting transactions: %v", err)
+	}
+
+	if existing != nil {
+		log.Printf("Already imported %s as import #%d, skipping", source, existing.ID)
+		return &FileReport{File: filename, Source: source, Status: StatusAlreadyImported}
+	}
+
+	report.Status = StatusImported
+	if record.Inserted > 0 {
+		log.Printf("Inserted %d transactions from %s as import #%d", record.Inserted, filename, record.ID)
+	} else {
+		log.Printf("No new transactions found in %s", filename)
+	}
+
+	return report
+}
+
+// statementAccount decides which account a statement's transactions belong
+// to: the one named on the command line, then the one the parser detected.
+// Chime statements that don't name their account fall back to the file name.
+func statementAccount(statement *Statement, parserName, source, accountName string) *database.Account {
+	switch {
+	case accountName != "":
+		return &database.Account{Name: accountName, Kind: database.AccountOther}
+	case statement.Account != nil:
+		return statement.Account
+	case strings.Contains(parserName, "chime"):
+		return chimeAccountFromFilename(source)
+	default:
+		return nil
+	}
+}
+
+// reportReconciliation logs whether the statement's transactions account for
+// the change between its opening and closing balances, and returns the
+// outcome for the file report
+func reportReconciliation(filename string, statement *Statement) string {
+	if !statement.CanReconcile() {
+		log.Printf("No statement balances found in %s, skipping reconciliation", filename)
+		return ""
+	}
+
+	period := statement.Period()
+	if period == "" {
+		period = "unknown period"
+	}
+
+	if err := statement.Reconcile(); err != nil {
+		log.Printf("RECONCILIATION MISMATCH in %s (%s): %v", filename, period, err)
+		return "mismatch: " + err.Error()
+	}
+
+	log.Printf("Reconciled %d transactions in %s (%s)", len(statement.Transactions), filename, period)
+	return "reconciled"
+}
+
+// isDuplicate reports whether the transaction is already in the database.
+// Transactions carrying an OFX FITID are matched on it alone since it is
+// stable across exports; everything else is matched on its contents. Rows
+// imported before accounts were tracked match any account.
+func isDuplicate(db *gorm.DB, transaction database.Transaction) (bool, error) {
+	query := db.Where("fit_id = ?", transaction.FITID)
+
+	if transaction.FITID == "" {
+		query = db.Where("date = ? AND description = ? AND amount_cents = ? AND net_amount_cents = ? AND settle_date = ?",
+			transaction.Date,
+			transaction.Description,
+			transaction.Amount,
+			transaction.NetAmount,
+			transaction.SettleDate)
+	}
+
+	if transaction.AccountID != nil {
+		query = query.Where("account_id = ? OR account_id IS NULL", *transaction.AccountID)
+	}
+
+	var count int64
+	if err := query.Model(&database.Transaction{}).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check for duplicates: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// cleanupTxtFiles removes all .txt files in the provided list
+func cleanupTxtFiles(files []string) {
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			log.Printf("Failed to delete %s: %v", file, err)
+		} else {
+			log.Printf("Deleted %s", file)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+// hashFile returns the hex encoded SHA-256 of a file's contents
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// findImport returns the import of a file with the given content hash, or
+// nil if the file has never been imported
+func findImport(db *gorm.DB, contentHash string) (*database.Import, error) {
+	var existing []database.Import
+
+	// Find rather than First, so a miss isn't logged as an error
+	if err := db.Where("content_hash = ?", contentHash).Limit(1).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	if len(existing) == 0 {
+		return nil, nil
+	}
+
+	return &existing[0], nil
+}
+
+// newImport builds the import record for a parsed statement
+func newImport(path, contentHash, parser string, statement *Statement) *database.Import {
+	record := &database.Import{
+		Path:        path,
+		ContentHash: contentHash,
+		Parser:      parser,
+	}
+
+	if !statement.PeriodStart.IsZero() {
+		record.PeriodStart = &statement.PeriodStart
+	}
+	if !statement.PeriodEnd.IsZero() {
+		record.PeriodEnd = &statement.PeriodEnd
+	}
+
+	return record
+}
+
+// resolveImport finds an import from a reference that is either the path of
+// a source file, its full content hash, or an unambiguous hash prefix
+func resolveImport(db *gorm.DB, ref string) (*database.Import, error) {
+	contentHash := ref
+	if _, err := os.Stat(ref); err == nil {
+		if contentHash, err = hashFile(ref); err != nil {
+			return nil, err
+		}
+	}
+
+	var matches []database.Import
+	if err := db.Where("content_hash LIKE ?", contentHash+"%").Limit(2).Find(&matches).Error; err != nil {
+		return nil, err
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no import found for %q", ref)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("%q matches more than one import, use a longer hash", ref)
+	}
+}
+
+// undoImport removes every transaction created by an import, along with the
+// import record itself, in a single database transaction. Transfers paired
+// with a removed transaction are unpaired. It returns the number of
+// transactions removed.
+func undoImport(db *gorm.DB, record *database.Import) (int64, error) {
+	var removed int64
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&database.Transaction{}).
+			Where("transfer_id IN (?)", tx.Model(&database.Transaction{}).Select("id").Where("import_id = ?", record.ID)).
+			Update("transfer_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to unpair transfers: %w", err)
+		}
+
+		result := tx.Where("import_id = ?", record.ID).Delete(&database.Transaction{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete transactions: %w", result.Error)
+		}
+		removed = result.RowsAffected
+
+		if err := tx.Delete(record).Error; err != nil {
+			return fmt.Errorf("failed to delete import record: %w", err)
+		}
+
+		return nil
+	})
+
+	return removed, err
+}
+
+// listImports prints the import history, newest first
+func listImports(db *gorm.DB, w io.Writer) error {
+	var imports []database.Import
+	if err := db.Order("created_at DESC").Find(&imports).Error; err != nil {
+		return err
+	}
+
+	for _, record := range imports {
+		period := "unknown period"
+		if record.PeriodStart != nil && record.PeriodEnd != nil {
+			period = record.PeriodStart.Format("2006-01-02") + " to " + record.PeriodEnd.Format("2006-01-02")
+		}
+
+		fmt.Fprintf(w, "#%d  %s  %s  %-10s %s  inserted %d, skipped %d  %s\n",
+			record.ID,
+			record.CreatedAt.Format(time.DateTime),
+			record.ContentHash[:12],
+			record.Parser,
+			period,
+			record.Inserted,
+			record.Skipped,
+			record.Path)
+	}
+
+	return nil
+}
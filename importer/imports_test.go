@@ -0,0 +1,292 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+func TestProcessFile_RecordsImportAndSkipsReimport(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := initDB(filepath.Join(dir, "transactions.db"))
+	if err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+
+	statement := filepath.Join(dir, "statement.txt")
+	if err := os.WriteFile(statement, []byte(chimeStatementWithSummary), 0o600); err != nil {
+		t.Fatalf("failed to write statement: %v", err)
+	}
+
+	processFile(statement, statement, "", "", db)
+	processFile(statement, statement, "", "", db)
+
+	var imports []database.Import
+	if err := db.Find(&imports).Error; err != nil {
+		t.Fatalf("failed to read imports: %v", err)
+	}
+
+	if len(imports) != 1 {
+		t.Fatalf("expected 1 import, got %d", len(imports))
+	}
+
+	record := imports[0]
+	if record.Parser != "chime" || record.Inserted != 3 || record.Skipped != 0 || record.PeriodStart == nil {
+		t.Errorf("unexpected import: %+v", record)
+	}
+
+	var linked int64
+	if err := db.Model(&database.Transaction{}).Where("import_id = ?", record.ID).Count(&linked).Error; err != nil {
+		t.Fatalf("failed to count transactions: %v", err)
+	}
+
+	if linked != 3 {
+		t.Errorf("expected 3 transactions linked to the import, got %d", linked)
+	}
+}
+
+func TestProcessFilesConcurrently_FileDatabase(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := initDB(filepath.Join(dir, "transactions.db"))
+	if err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+
+	var files []string
+	for i := 1; i <= 8; i++ {
+		text := fmt.Sprintf("Savings Account Statement\n"+
+			"7/%02d/2024   Transfer from Chime Checking Account     Transfer    $%d.00    $%d.00      7/%02d/2024\n", i, i, i, i)
+
+		path := filepath.Join(dir, fmt.Sprintf("statement-%d.txt", i))
+		if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+			t.Fatalf("failed to write statement: %v", err)
+		}
+		files = append(files, path)
+	}
+
+	for _, report := range processFilesConcurrently(files, nil, "", "", db) {
+		if report.Status != StatusImported {
+			t.Errorf("expected %s to be imported, got %s: %s", report.File, report.Status, report.Error)
+		}
+	}
+
+	var count int64
+	if err := db.Model(&database.Transaction{}).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count transactions: %v", err)
+	}
+
+	if count != int64(len(files)) {
+		t.Errorf("expected %d transactions, got %d", len(files), count)
+	}
+}
+
+func TestProcessFilesConcurrently_SameContents(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := initDB(filepath.Join(dir, "transactions.db"))
+	if err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+
+	// The same statement downloaded several times
+	var files []string
+	for i := 0; i < 6; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("statement (%d).txt", i))
+		if err := os.WriteFile(path, []byte(chimeStatementWithSummary), 0o600); err != nil {
+			t.Fatalf("failed to write statement: %v", err)
+		}
+		files = append(files, path)
+	}
+
+	statuses := map[string]int{}
+	for _, report := range processFilesConcurrently(files, nil, "", "", db) {
+		statuses[report.Status]++
+	}
+
+	if statuses[StatusImported] != 1 || statuses[StatusAlreadyImported] != len(files)-1 {
+		t.Errorf("expected 1 import and %d already imported, got %v", len(files)-1, statuses)
+	}
+
+	var count int64
+	if err := db.Model(&database.Transaction{}).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count transactions: %v", err)
+	}
+
+	if count != 3 {
+		t.Errorf("expected 3 transactions, got %d", count)
+	}
+}
+
+func TestUndoImport(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := initDB(filepath.Join(dir, "transactions.db"))
+	if err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+
+	statement := filepath.Join(dir, "statement.txt")
+	if err := os.WriteFile(statement, []byte(chimeStatementWithSummary), 0o600); err != nil {
+		t.Fatalf("failed to write statement: %v", err)
+	}
+
+	processFile(statement, statement, "", "", db)
+
+	record, err := resolveImport(db, statement)
+	if err != nil {
+		t.Fatalf("failed to resolve import by path: %v", err)
+	}
+
+	if byPrefix, err := resolveImport(db, record.ContentHash[:8]); err != nil || byPrefix.ID != record.ID {
+		t.Fatalf("failed to resolve import by hash prefix: %v", err)
+	}
+
+	removed, err := undoImport(db, record)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if removed != 3 {
+		t.Errorf("expected 3 transactions removed, got %d", removed)
+	}
+
+	var remaining int64
+	db.Model(&database.Transaction{}).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("expected no transactions left, got %d", remaining)
+	}
+
+	if _, err := resolveImport(db, statement); err == nil {
+		t.Errorf("expected the import record to be removed")
+	}
+}
+
+func TestInitScratchDB_LeavesDatabaseUntouched(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "transactions.db")
+
+	db, err := initDB(dbPath)
+	if err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+
+	statement := filepath.Join(dir, "statement.txt")
+	if err := os.WriteFile(statement, []byte(chimeStatementWithSummary), 0o600); err != nil {
+		t.Fatalf("failed to write statement: %v", err)
+	}
+
+	scratch, cleanup, err := initScratchDB(dbPath)
+	if err != nil {
+		t.Fatalf("failed to initialize scratch database: %v", err)
+	}
+	defer cleanup()
+
+	report := newImportReport(true, []*FileReport{processFile(statement, statement, "", "", scratch)})
+	if report.Inserted != 3 {
+		t.Errorf("expected 3 transactions to be reported as inserted, got %d", report.Inserted)
+	}
+
+	var count int64
+	db.Model(&database.Transaction{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected the real database to be untouched, found %d transactions", count)
+	}
+}
+
+func TestDryRunReport(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "transactions.db")
+
+	db, err := initDB(dbPath)
+	if err != nil {
+		t.Fatalf("failed to initialize database: %v", err)
+	}
+
+	const whalewatch = "7/19/2024   Islandadv.Whalewatch                    Purchase    -$274.18   -$274.18     7/20/2024\n"
+
+	seed := filepath.Join(dir, "seed.txt")
+	if err := os.WriteFile(seed, []byte(whalewatch), 0o600); err != nil {
+		t.Fatalf("failed to write statement: %v", err)
+	}
+
+	if report := processFile(seed, seed, "", "", db); report.Status != StatusImported {
+		t.Fatalf("failed to seed database: %s %s", report.Status, report.Error)
+	}
+
+	// One duplicate of the seeded transaction, one new one and one line
+	// with an impossible date
+	statement := filepath.Join(dir, "statement.txt")
+	text := whalewatch +
+		"7/20/2024   Payroll                                 Deposit     $1,234.56  $1,234.56    7/20/2024\n" +
+		"7/45/2024   Coffee                                  Purchase    -$4.50     -$4.50       7/46/2024\n"
+	if err := os.WriteFile(statement, []byte(text), 0o600); err != nil {
+		t.Fatalf("failed to write statement: %v", err)
+	}
+
+	scratch, cleanup, err := initScratchDB(dbPath)
+	if err != nil {
+		t.Fatalf("failed to initialize scratch database: %v", err)
+	}
+	defer cleanup()
+
+	report := newImportReport(true, processFilesConcurrently([]string{statement}, nil, "", "", scratch))
+
+	var out bytes.Buffer
+	report.WriteText(&out)
+
+	for _, want := range []string{
+		"statement.txt [would import]",
+		"  + 2024-07-20  Payroll",
+		"  = 2024-07-19  Islandadv.Whalewatch",
+		"(duplicate)",
+		"  ! line 3: invalid date",
+		"Would insert 1 transactions, skipped 1 duplicates, rejected 1 records across 1 files",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected the text report to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := report.WriteJSON(&out); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+
+	var totals struct {
+		DryRun     bool `json:"dry_run"`
+		Inserted   int  `json:"inserted"`
+		Duplicates int  `json:"duplicates"`
+		Rejected   int  `json:"rejected"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &totals); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+
+	if !totals.DryRun || totals.Inserted != 1 || totals.Duplicates != 1 || totals.Rejected != 1 {
+		t.Errorf("unexpected JSON totals %+v", totals)
+	}
+
+	if _, err := os.Stat(statement); err != nil {
+		t.Errorf("expected the text file to be left in place: %v", err)
+	}
+}
+
+func TestIsDuplicate_QueryError(t *testing.T) {
+	// Without migrations there is no transactions table to query
+	db, err := database.Open(filepath.Join(t.TempDir(), "transactions.db"))
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+
+	if _, err := isDuplicate(db, database.Transaction{Description: "Coffee"}); err == nil {
+		t.Error("expected the failed query to be reported")
+	}
+}
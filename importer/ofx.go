@@ -0,0 +1,301 @@
+package importer
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+func init() {
+	RegisterParser(ofxParser{})
+}
+
+// ofxTypes maps OFX TRNTYPE values onto the transaction types used by
+// Chime statements
+var ofxTypes = map[string]string{
+	"CREDIT":      "Deposit",
+	"DEP":         "Deposit",
+	"DIRECTDEP":   "Deposit",
+	"INT":         "Deposit",
+	"DIV":         "Deposit",
+	"DEBIT":       "Purchase",
+	"POS":         "Purchase",
+	"CHECK":       "Purchase",
+	"PAYMENT":     "Purchase",
+	"DIRECTDEBIT": "Direct Debit",
+	"REPEATPMT":   "Direct Debit",
+	"ATM":         "ATM Withdrawal",
+	"CASH":        "ATM Withdrawal",
+	"FEE":         "Fee",
+	"SRVCHG":      "Fee",
+	"XFER":        "Transfer",
+}
+
+// ofxParser parses OFX and QFX files in both the SGML (1.x) and XML (2.x)
+// flavors. Only STMTTRN records are read; every other aggregate is ignored.
+type ofxParser struct{}
+
+func (ofxParser) Name() string {
+	return "ofx"
+}
+
+func (ofxParser) Detect(header []string) bool {
+	for _, line := range header {
+		upper := strings.ToUpper(line)
+		if strings.Contains(upper, "OFXHEADER") || strings.Contains(upper, "<OFX>") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Parse reads every STMTTRN record. The statement period comes from the
+// transaction list's DTSTART and DTEND and the closing balance from LEDGERBAL;
+// OFX has no opening balance so these statements can't be reconciled. Files
+// holding statements for more than one account are refused, since a
+// statement's transactions all go to one account.
+func (ofxParser) Parse(r io.Reader) (*Statement, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		statement = &Statement{}
+		record    map[string]string
+		inLedger  bool
+		currency  = database.DefaultCurrency
+
+		// The statement's own account, from BANKACCTFROM or CCACCTFROM
+		inAccount   bool
+		accountTags = map[string]string{}
+		accountIDs  = map[string]bool{}
+	)
+
+	for _, tok := range tokenizeOFX(string(data)) {
+		switch {
+		case record == nil && (tok.name == "BANKACCTFROM" || tok.name == "CCACCTFROM"):
+			inAccount = !tok.closing
+			if tok.name == "CCACCTFROM" {
+				accountTags["ACCTTYPE"] = "CREDITCARD"
+			}
+
+		case record == nil && (inAccount || tok.name == "ORG") && !tok.closing && tok.value != "":
+			if inAccount && tok.name == "ACCTID" {
+				accountIDs[tok.value] = true
+			}
+			if _, seen := accountTags[tok.name]; !seen {
+				accountTags[tok.name] = tok.value
+			}
+
+		case tok.name == "CURDEF" && tok.value != "":
+			currency = strings.ToUpper(tok.value)
+
+		case tok.name == "LEDGERBAL":
+			inLedger = !tok.closing
+
+		case inLedger && tok.name == "BALAMT" && statement.ClosingBalance == nil:
+			if balance, err := parseBalance(ofxDecimal(tok.value)); err == nil {
+				statement.ClosingBalance = balance
+			}
+
+		case record == nil && (tok.name == "DTSTART" || tok.name == "DTEND"):
+			date, err := parseOFXDate(tok.value)
+			if err != nil {
+				continue
+			}
+			if tok.name == "DTSTART" && statement.PeriodStart.IsZero() {
+				statement.PeriodStart = date
+			} else if tok.name == "DTEND" && statement.PeriodEnd.IsZero() {
+				statement.PeriodEnd = date
+			}
+
+		case tok.name == "STMTTRN" && !tok.closing:
+			record = map[string]string{}
+
+		case tok.name == "STMTTRN" && tok.closing:
+			if record == nil {
+				continue
+			}
+
+			transaction, err := ofxTransaction(record, currency)
+			if err != nil {
+				statement.reject(0, "FITID "+record["FITID"], err.Error())
+			} else {
+				statement.Transactions = append(statement.Transactions, transaction)
+			}
+			record = nil
+
+		case record != nil && !tok.closing && tok.value != "":
+			// NAME appears both directly in STMTTRN and inside PAYEE, the
+			// first one seen wins
+			if _, seen := record[tok.name]; !seen {
+				record[tok.name] = tok.value
+			}
+		}
+	}
+
+	if len(accountIDs) > 1 {
+		return nil, fmt.Errorf("the file holds statements for %d accounts, download one file per account "+
+			"or split it so each file has a single STMTRS or CCSTMTRS", len(accountIDs))
+	}
+
+	statement.Account = ofxAccount(accountTags)
+
+	return statement, nil
+}
+
+// ofxAccount builds the statement's account from the ORG, ACCTID and
+// ACCTTYPE elements, or returns nil when there is no account number
+func ofxAccount(tags map[string]string) *database.Account {
+	number := tags["ACCTID"]
+	if number == "" {
+		return nil
+	}
+
+	if len(number) > 4 {
+		number = number[len(number)-4:]
+	}
+
+	institution := tags["ORG"]
+	if institution == "" {
+		institution = "OFX"
+	}
+
+	kind := database.AccountOther
+	switch strings.ToUpper(tags["ACCTTYPE"]) {
+	case "CHECKING":
+		kind = database.AccountChecking
+	case "SAVINGS", "MONEYMRKT":
+		kind = database.AccountSavings
+	case "CREDITLINE", "CREDITCARD":
+		kind = database.AccountCredit
+	}
+
+	return &database.Account{
+		Name:        fmt.Sprintf("%s %s %s", institution, kind, number),
+		Institution: institution,
+		Kind:        kind,
+		Number:      number,
+	}
+}
+
+// ofxToken is a single tag from an OFX document. For SGML elements, which
+// have no closing tag, value holds the text that follows the tag.
+type ofxToken struct {
+	name    string
+	value   string
+	closing bool
+}
+
+// tokenizeOFX splits an OFX document into tags. It works for both flavors
+// because it only relies on tags being delimited by angle brackets and
+// element values running up to the next tag.
+func tokenizeOFX(doc string) []ofxToken {
+	var tokens []ofxToken
+
+	for {
+		start := strings.IndexByte(doc, '<')
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(doc[start:], '>')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		tag := doc[start+1 : end]
+		doc = doc[end+1:]
+
+		// Skip the XML declaration, processing instructions and comments
+		if strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!") {
+			continue
+		}
+
+		next := strings.IndexByte(doc, '<')
+		if next < 0 {
+			next = len(doc)
+		}
+
+		tok := ofxToken{name: strings.ToUpper(strings.TrimSpace(tag))}
+		if strings.HasPrefix(tok.name, "/") {
+			tok.closing = true
+			tok.name = tok.name[1:]
+		} else {
+			tok.value = html.UnescapeString(strings.TrimSpace(doc[:next]))
+		}
+
+		tokens = append(tokens, tok)
+	}
+
+	return tokens
+}
+
+// ofxTransaction converts the elements of a STMTTRN record into a transaction
+// in the statement's default currency
+func ofxTransaction(record map[string]string, currency string) (database.Transaction, error) {
+	posted, err := parseOFXDate(record["DTPOSTED"])
+	if err != nil {
+		return database.Transaction{}, fmt.Errorf("invalid DTPOSTED: %w", err)
+	}
+
+	date := posted
+	if record["DTUSER"] != "" {
+		if date, err = parseOFXDate(record["DTUSER"]); err != nil {
+			return database.Transaction{}, fmt.Errorf("invalid DTUSER: %w", err)
+		}
+	}
+
+	amount, err := database.ParseMoney(ofxDecimal(record["TRNAMT"]))
+	if err != nil {
+		return database.Transaction{}, fmt.Errorf("invalid TRNAMT: %w", err)
+	}
+
+	description := record["NAME"]
+	if description == "" {
+		description = record["MEMO"]
+	}
+
+	transactionType, ok := ofxTypes[strings.ToUpper(record["TRNTYPE"])]
+	if !ok {
+		transactionType = "Purchase"
+		if amount > 0 {
+			transactionType = "Deposit"
+		}
+	}
+
+	return database.Transaction{
+		Date:        date,
+		Description: description,
+		Type:        transactionType,
+		Amount:      amount,
+		NetAmount:   amount,
+		Currency:    currency,
+		SettleDate:  posted,
+		FITID:       record["FITID"],
+	}, nil
+}
+
+// ofxDecimal normalizes an OFX amount to use a decimal point, since some
+// European exports use a decimal comma
+func ofxDecimal(s string) string {
+	return strings.ReplaceAll(s, ",", ".")
+}
+
+// parseOFXDate parses the date part of an OFX datetime such as
+// "20240719120000.000[-7:MST]". The time of day is dropped to match the
+// day-level dates of Chime statements.
+func parseOFXDate(s string) (time.Time, error) {
+	if len(s) < 8 {
+		return time.Time{}, fmt.Errorf("date %q is too short", s)
+	}
+
+	return time.Parse("20060102", s[:8])
+}
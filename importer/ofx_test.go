@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const sgmlOFX = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<STMTRS>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>POS
+<DTPOSTED>20240720120000.000[-7:MST]
+<DTUSER>20240719
+<TRNAMT>-274.18
+<FITID>202407190001
+<NAME>Islandadv.Whalewatch
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>XFER
+<DTPOSTED>20240719
+<TRNAMT>275.00
+<FITID>202407190002
+<NAME>Transfer from Savings &amp; More
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+const xmlOFX = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+  <CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS><BANKTRANLIST>
+    <STMTTRN>
+      <TRNTYPE>DEBIT</TRNTYPE>
+      <DTPOSTED>20240301</DTPOSTED>
+      <TRNAMT>-4.50</TRNAMT>
+      <FITID>abc-1</FITID>
+      <PAYEE><NAME>Coffee Shop</NAME></PAYEE>
+      <MEMO>Latte</MEMO>
+    </STMTTRN>
+  </BANKTRANLIST></CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+func TestOFXParser_SGML(t *testing.T) {
+	p, err := detectParser(statementHeader(sgmlOFX))
+	if err != nil || p.Name() != "ofx" {
+		t.Fatalf("expected ofx parser, got %v, %v", p, err)
+	}
+
+	statement, err := p.Parse(strings.NewReader(sgmlOFX))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transactions := statement.Transactions
+
+	if len(transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(transactions))
+	}
+
+	first := transactions[0]
+	if first.FITID != "202407190001" || first.Type != "Purchase" || first.Amount != -27418 {
+		t.Errorf("unexpected transaction: %+v", first)
+	}
+
+	if !first.Date.Equal(time.Date(2024, 7, 19, 0, 0, 0, 0, time.UTC)) ||
+		!first.SettleDate.Equal(time.Date(2024, 7, 20, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected dates: %v, %v", first.Date, first.SettleDate)
+	}
+
+	if transactions[1].Description != "Transfer from Savings & More" || transactions[1].Type != "Transfer" {
+		t.Errorf("unexpected transaction: %+v", transactions[1])
+	}
+}
+
+func TestOFXParser_XML(t *testing.T) {
+	p, err := detectParser(statementHeader(xmlOFX))
+	if err != nil || p.Name() != "ofx" {
+		t.Fatalf("expected ofx parser, got %v, %v", p, err)
+	}
+
+	statement, err := p.Parse(strings.NewReader(xmlOFX))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transactions := statement.Transactions
+
+	if len(transactions) != 1 {
+		t.Fatalf("expected 1 transaction, got %d", len(transactions))
+	}
+
+	if transactions[0].Description != "Coffee Shop" || transactions[0].FITID != "abc-1" {
+		t.Errorf("unexpected transaction: %+v", transactions[0])
+	}
+}
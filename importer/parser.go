@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// headerLines is how many lines from the top of a statement are handed to
+// each parser's Detect method when sniffing the file format.
+const headerLines = 64
+
+// StatementParser turns the text of a single bank statement into transactions.
+// Each bank or statement layout gets its own implementation, registered with
+// RegisterParser so the importer can pick one by sniffing the file header.
+type StatementParser interface {
+	// Name is a short, unique identifier for the parser, e.g. "chime".
+	Name() string
+
+	// Detect reports whether the parser recognizes a statement from its
+	// first few lines.
+	Detect(header []string) bool
+
+	// Parse reads a statement and returns the transactions found in it,
+	// along with its period and balances when the format provides them.
+	Parse(r io.Reader) (*Statement, error)
+}
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = map[string]StatementParser{}
+)
+
+// RegisterParser makes a statement parser available to the importer. It
+// panics if a parser with the same name is already registered.
+func RegisterParser(p StatementParser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+
+	if _, dup := parsers[p.Name()]; dup {
+		panic("importer: RegisterParser called twice for parser " + p.Name())
+	}
+
+	parsers[p.Name()] = p
+}
+
+// Parsers returns the names of all registered parsers in sorted order
+func Parsers() []string {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// lookupParser returns the registered parser with the given name
+func lookupParser(name string) (StatementParser, error) {
+	parsersMu.RLock()
+	p, ok := parsers[name]
+	parsersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown parser %q (available: %s)", name, strings.Join(Parsers(), ", "))
+	}
+
+	return p, nil
+}
+
+// detectParser returns the first registered parser, in name order, that
+// recognizes the given statement header
+func detectParser(header []string) (StatementParser, error) {
+	for _, name := range Parsers() {
+		parsersMu.RLock()
+		p := parsers[name]
+		parsersMu.RUnlock()
+
+		if p.Detect(header) {
+			return p, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no registered parser recognizes this statement")
+}
+
+// statementHeader returns up to headerLines lines from the start of content
+func statementHeader(content string) []string {
+	lines := strings.SplitN(content, "\n", headerLines+1)
+	if len(lines) > headerLines {
+		lines = lines[:headerLines]
+	}
+
+	return lines
+}
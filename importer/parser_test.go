@@ -0,0 +1,62 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const chimeStatement = `Checking Account Statement
+Chime
+                                              Member Services
+Transactions
+TRANSACTION DATE   DESCRIPTION                      TYPE        AMOUNT     NET AMOUNT   SETTLEMENT DATE
+7/19/2024   Islandadv.Whalewatch                    Purchase    -$274.18   -$274.18     7/20/2024
+7/19/2024   Transfer from Chime Savings Account     Transfer    $275.00    $275.00      7/19/2024
+Page 1 of 3
+`
+
+func TestDetectParser_Chime(t *testing.T) {
+	p, err := detectParser(statementHeader(chimeStatement))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Name() != "chime" {
+		t.Errorf("expected chime parser, got %s", p.Name())
+	}
+}
+
+func TestDetectParser_Unknown(t *testing.T) {
+	if _, err := detectParser([]string{"Some other bank", "Nothing to see here"}); err == nil {
+		t.Errorf("expected an error for an unrecognized statement, got nil")
+	}
+}
+
+func TestLookupParser_Unknown(t *testing.T) {
+	if _, err := lookupParser("nope"); err == nil {
+		t.Errorf("expected an error for an unknown parser name, got nil")
+	}
+}
+
+func TestChimeParser_Parse(t *testing.T) {
+	statement, err := chimeParser{}.Parse(strings.NewReader(chimeStatement))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transactions := statement.Transactions
+
+	if len(transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(transactions))
+	}
+
+	first := transactions[0]
+	if first.Description != "Islandadv.Whalewatch" || first.Type != "Purchase" || first.Amount != -27418 {
+		t.Errorf("unexpected transaction: %+v", first)
+	}
+
+	if !first.SettleDate.Equal(time.Date(2024, 7, 20, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected settlement date: %v", first.SettleDate)
+	}
+}
@@ -0,0 +1,128 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PDF text extraction backends
+const (
+	// PDFBackendBuiltin extracts text in-process with no external binaries
+	PDFBackendBuiltin = "builtin"
+
+	// PDFBackendPdftotext shells out to poppler's `pdftotext -layout`
+	PDFBackendPdftotext = "pdftotext"
+)
+
+// extractPDFText returns the text of a PDF using the given backend
+func extractPDFText(backend, pdfFile string) (string, error) {
+	switch backend {
+	case PDFBackendBuiltin:
+		return builtinPDFText(pdfFile)
+	case PDFBackendPdftotext:
+		out, err := exec.Command("pdftotext", "-layout", pdfFile, "-").Output()
+		return string(out), err
+	default:
+		return "", fmt.Errorf("unknown PDF backend %q", backend)
+	}
+}
+
+// builtinPDFText extracts the text of every page of a PDF, rebuilding lines
+// from glyph positions so columns stay separated by whitespace the way
+// `pdftotext -layout` output does
+func builtinPDFText(pdfFile string) (text string, err error) {
+	f, r, err := pdf.Open(pdfFile)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// The pdf package panics on malformed content streams
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("failed to read PDF content: %v", p)
+		}
+	}()
+
+	var sb strings.Builder
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+
+		sb.WriteString(layoutText(page.Content().Text))
+		sb.WriteString("\f")
+	}
+
+	return sb.String(), nil
+}
+
+// layoutText arranges the glyphs of a single page into lines. Glyphs are
+// grouped into a line when their baselines are within half a font size of
+// each other, and horizontal gaps wider than a quarter of the font size
+// become spaces, roughly one per half font size of gap.
+func layoutText(texts []pdf.Text) string {
+	if len(texts) == 0 {
+		return ""
+	}
+
+	glyphs := make([]pdf.Text, len(texts))
+	copy(glyphs, texts)
+
+	// Top of the page first; joinLine orders each line left to right
+	sort.SliceStable(glyphs, func(i, j int) bool { return glyphs[i].Y > glyphs[j].Y })
+
+	var (
+		lines []string
+		line  []pdf.Text
+	)
+
+	flush := func() {
+		if len(line) > 0 {
+			lines = append(lines, joinLine(line))
+			line = nil
+		}
+	}
+
+	for _, g := range glyphs {
+		if len(line) > 0 && math.Abs(line[0].Y-g.Y) > lineTolerance(line[0], g) {
+			flush()
+		}
+		line = append(line, g)
+	}
+	flush()
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// joinLine concatenates the glyphs of one line from left to right
+func joinLine(line []pdf.Text) string {
+	sort.SliceStable(line, func(i, j int) bool { return line[i].X < line[j].X })
+
+	var sb strings.Builder
+	for i, g := range line {
+		if i > 0 {
+			prev := line[i-1]
+			size := math.Max(g.FontSize, 1)
+
+			if gap := g.X - (prev.X + prev.W); gap > size/4 {
+				sb.WriteString(strings.Repeat(" ", int(math.Max(1, math.Round(gap/(size/2))))))
+			}
+		}
+		sb.WriteString(g.S)
+	}
+
+	return strings.TrimRight(sb.String(), " ")
+}
+
+// lineTolerance is how far apart two baselines can be while still being
+// treated as the same line
+func lineTolerance(a, b pdf.Text) float64 {
+	return math.Max(math.Max(a.FontSize, b.FontSize), 1) / 2
+}
@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestPDF writes a single page PDF showing each string at its (x, y)
+// position in 10pt Helvetica
+func writeTestPDF(t *testing.T, texts map[[2]int]string) string {
+	t.Helper()
+
+	var content strings.Builder
+	for pos, s := range texts {
+		fmt.Fprintf(&content, "BT /F1 10 Tf %d %d Td (%s) Tj ET\n", pos[0], pos[1], s)
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+
+	var doc strings.Builder
+	doc.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = doc.Len()
+		fmt.Fprintf(&doc, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := doc.Len()
+	fmt.Fprintf(&doc, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&doc, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&doc, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	path := filepath.Join(t.TempDir(), "statement.pdf")
+	if err := os.WriteFile(path, []byte(doc.String()), 0o600); err != nil {
+		t.Fatalf("failed to write test PDF: %v", err)
+	}
+
+	return path
+}
+
+func TestBuiltinPDFText_ChimeLayout(t *testing.T) {
+	path := writeTestPDF(t, map[[2]int]string{
+		{50, 700}:  "Chime Checking Account",
+		{50, 650}:  "7/19/2024",
+		{120, 650}: "Islandadv.Whalewatch",
+		{300, 650}: "Purchase",
+		{380, 650}: "-$274.18",
+		{450, 650}: "-$274.18",
+		{520, 650}: "7/20/2024",
+	})
+
+	text, err := builtinPDFText(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p, err := detectParser(statementHeader(text))
+	if err != nil || p.Name() != "chime" {
+		t.Fatalf("expected chime parser, got %v, %v\n%s", p, err, text)
+	}
+
+	statement, err := p.Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	transactions := statement.Transactions
+
+	if len(transactions) != 1 {
+		t.Fatalf("expected 1 transaction, got %d from:\n%s", len(transactions), text)
+	}
+
+	if transactions[0].Description != "Islandadv.Whalewatch" || transactions[0].Amount != -27418 {
+		t.Errorf("unexpected transaction: %+v", transactions[0])
+	}
+}
@@ -0,0 +1,121 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+// File statuses reported by processFile
+const (
+	StatusImported        = "imported"
+	StatusAlreadyImported = "already-imported"
+	StatusUnrecognized    = "unrecognized"
+	StatusFailed          = "failed"
+)
+
+// FileReport describes what happened, or in a dry run what would happen,
+// to a single file
+type FileReport struct {
+	File           string                 `json:"file"`
+	Source         string                 `json:"source"`
+	Parser         string                 `json:"parser,omitempty"`
+	Account        string                 `json:"account,omitempty"`
+	Status         string                 `json:"status"`
+	Error          string                 `json:"error,omitempty"`
+	Period         string                 `json:"period,omitempty"`
+	Reconciliation string                 `json:"reconciliation,omitempty"`
+	Inserted       []database.Transaction `json:"inserted"`
+	Duplicates     []database.Transaction `json:"duplicates"`
+	Rejected       []Rejection            `json:"rejected"`
+}
+
+// ImportReport is the result of processing every file in a run
+type ImportReport struct {
+	DryRun     bool          `json:"dry_run"`
+	Files      []*FileReport `json:"files"`
+	Inserted   int           `json:"inserted"`
+	Duplicates int           `json:"duplicates"`
+	Rejected   int           `json:"rejected"`
+
+	// TransfersPaired counts transfers between the user's own accounts that
+	// were linked after importing
+	TransfersPaired int `json:"transfers_paired"`
+}
+
+// newImportReport totals the per-file reports
+func newImportReport(dryRun bool, files []*FileReport) *ImportReport {
+	report := &ImportReport{DryRun: dryRun, Files: files}
+
+	for _, f := range files {
+		report.Inserted += len(f.Inserted)
+		report.Duplicates += len(f.Duplicates)
+		report.Rejected += len(f.Rejected)
+	}
+
+	return report
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *ImportReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r)
+}
+
+// WriteText writes a human-readable report listing every transaction that
+// was, or would be, inserted, skipped as a duplicate, or rejected
+func (r *ImportReport) WriteText(w io.Writer) {
+	verb := "Inserted"
+	if r.DryRun {
+		verb = "Would insert"
+	}
+
+	for _, f := range r.Files {
+		status := f.Status
+		if r.DryRun && status == StatusImported {
+			status = "would import"
+		}
+
+		fmt.Fprintf(w, "%s [%s] %s", filepath.Base(f.File), status, f.Parser)
+		if f.Account != "" {
+			fmt.Fprintf(w, " (%s)", f.Account)
+		}
+		if f.Period != "" {
+			fmt.Fprintf(w, " %s", f.Period)
+		}
+		fmt.Fprintln(w)
+
+		if f.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n", f.Error)
+		}
+		if f.Reconciliation != "" {
+			fmt.Fprintf(w, "  reconciliation: %s\n", f.Reconciliation)
+		}
+
+		for _, t := range f.Inserted {
+			fmt.Fprintf(w, "  + %s\n", formatTransaction(t))
+		}
+		for _, t := range f.Duplicates {
+			fmt.Fprintf(w, "  = %s (duplicate)\n", formatTransaction(t))
+		}
+		for _, rejection := range f.Rejected {
+			fmt.Fprintf(w, "  ! line %d: %s: %q\n", rejection.Line, rejection.Reason, rejection.Text)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%s %d transactions, skipped %d duplicates, rejected %d records across %d files\n",
+		verb, r.Inserted, r.Duplicates, r.Rejected, len(r.Files))
+
+	if r.TransfersPaired > 0 {
+		fmt.Fprintf(w, "Paired %d transfers between accounts\n", r.TransfersPaired)
+	}
+}
+
+func formatTransaction(t database.Transaction) string {
+	return fmt.Sprintf("%s  %-40s %-14s %10s", t.Date.Format("2006-01-02"), t.Description, t.Type, t.NetAmount.Format(t.Currency))
+}
@@ -0,0 +1,131 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+// Statement is everything a parser found in a single statement file
+type Statement struct {
+	Transactions []database.Transaction
+
+	// Account is the account the statement belongs to, nil when the parser
+	// couldn't tell. It doesn't need to exist in the database yet.
+	Account *database.Account
+
+	// PeriodStart and PeriodEnd are zero when the format has no period
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+
+	// OpeningBalance and ClosingBalance are nil when the format doesn't
+	// print them
+	OpeningBalance *database.Money
+	ClosingBalance *database.Money
+
+	// Rejected holds records that looked like transactions but couldn't be
+	// parsed
+	Rejected []Rejection
+}
+
+// Rejection is a record a parser recognized as a transaction but couldn't
+// turn into one
+type Rejection struct {
+	Line   int    `json:"line,omitempty"` // 1-based line in the file, 0 when unknown
+	Text   string `json:"text"`
+	Reason string `json:"reason"`
+}
+
+// reject records a line that couldn't be parsed into a transaction
+func (s *Statement) reject(line int, text, reason string) {
+	s.Rejected = append(s.Rejected, Rejection{Line: line, Text: strings.TrimSpace(text), Reason: reason})
+}
+
+// ReconciliationError reports a statement whose transactions don't account
+// for the change between its opening and closing balances
+type ReconciliationError struct {
+	Opening database.Money
+	Closing database.Money
+	Net     database.Money
+}
+
+// Difference is the amount missing from the imported transactions
+func (e *ReconciliationError) Difference() database.Money {
+	return e.Closing - e.Opening - e.Net
+}
+
+func (e *ReconciliationError) Error() string {
+	return fmt.Sprintf("opening balance %s plus transactions %s is %s, but the closing balance is %s (difference %s)",
+		e.Opening, e.Net, e.Opening+e.Net, e.Closing, e.Difference())
+}
+
+// CanReconcile reports whether the statement has both balances
+func (s *Statement) CanReconcile() bool {
+	return s.OpeningBalance != nil && s.ClosingBalance != nil
+}
+
+// Reconcile checks that the opening balance plus the net amount of every
+// transaction equals the closing balance. It returns a *ReconciliationError
+// on a mismatch.
+func (s *Statement) Reconcile() error {
+	if !s.CanReconcile() {
+		return fmt.Errorf("statement has no opening and closing balances")
+	}
+
+	var net database.Money
+	for _, t := range s.Transactions {
+		net += t.NetAmount
+	}
+
+	if *s.OpeningBalance+net != *s.ClosingBalance {
+		return &ReconciliationError{
+			Opening: *s.OpeningBalance,
+			Closing: *s.ClosingBalance,
+			Net:     net,
+		}
+	}
+
+	return nil
+}
+
+// Period formats the statement period for logs, or "" when unknown
+func (s *Statement) Period() string {
+	if s.PeriodStart.IsZero() || s.PeriodEnd.IsZero() {
+		return ""
+	}
+
+	return s.PeriodStart.Format("2006-01-02") + " to " + s.PeriodEnd.Format("2006-01-02")
+}
+
+// statementDateLayouts are the date formats seen in statement headers
+var statementDateLayouts = []string{
+	"1/2/2006",
+	"01/02/2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2006-01-02",
+}
+
+// parseStatementDate parses a date in any of the statementDateLayouts
+func parseStatementDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range statementDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date %q", s)
+}
+
+// parseBalance parses a printed balance such as "-$1,234.56"
+func parseBalance(s string) (*database.Money, error) {
+	amount, err := database.ParseMoney(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &amount, nil
+}
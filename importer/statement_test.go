@@ -0,0 +1,72 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const chimeStatementWithSummary = `Chime Checking Account
+Statement period: 7/01/2024 - 7/31/2024
+Beginning balance on 7/01/2024                         $1,000.00
+Ending balance on 7/31/2024                            $2,235.38
+7/19/2024   Islandadv.Whalewatch                    Purchase    -$274.18   -$274.18     7/20/2024
+7/19/2024   Transfer from Chime Savings Account     Transfer    $275.00    $275.00      7/19/2024
+7/20/2024   Payroll                                 Deposit     $1,234.56  $1,234.56    7/20/2024
+`
+
+func TestChimeParser_Summary(t *testing.T) {
+	statement, err := chimeParser{}.Parse(strings.NewReader(chimeStatementWithSummary))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !statement.PeriodStart.Equal(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)) ||
+		!statement.PeriodEnd.Equal(time.Date(2024, 7, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected period: %s", statement.Period())
+	}
+
+	if statement.OpeningBalance == nil || *statement.OpeningBalance != 100000 {
+		t.Errorf("unexpected opening balance: %v", statement.OpeningBalance)
+	}
+
+	// Amounts of $1,000 and more carry a thousands separator
+	if len(statement.Transactions) != 3 || len(statement.Rejected) != 0 {
+		t.Fatalf("expected 3 transactions and no rejected lines, got %d and %+v", len(statement.Transactions), statement.Rejected)
+	}
+
+	if payroll := statement.Transactions[2]; payroll.Amount != 123456 || payroll.NetAmount != 123456 {
+		t.Errorf("unexpected payroll amounts: %s, %s", payroll.Amount, payroll.NetAmount)
+	}
+
+	if err := statement.Reconcile(); err != nil {
+		t.Errorf("expected statement to reconcile, got %v", err)
+	}
+}
+
+func TestStatement_ReconcileMismatch(t *testing.T) {
+	// The ending balance counts a $1,500.00 deposit the statement doesn't list
+	text := strings.Replace(chimeStatementWithSummary, "$2,235.38", "$3,735.38", 1)
+
+	statement, err := chimeParser{}.Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var mismatch *ReconciliationError
+	if err := statement.Reconcile(); !errors.As(err, &mismatch) {
+		t.Fatalf("expected a reconciliation error, got %v", err)
+	}
+
+	if mismatch.Difference() != 150000 {
+		t.Errorf("expected a difference of 1500.00, got %s", mismatch.Difference())
+	}
+}
+
+func TestStatement_ReconcileWithoutBalances(t *testing.T) {
+	statement := &Statement{}
+	if statement.CanReconcile() {
+		t.Errorf("expected a statement without balances not to be reconcilable")
+	}
+}
@@ -1,186 +1,129 @@
 package main
 
 import (
-	"context"
-	"database/sql"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
-	"time"
 
 	"gorm.io/gorm"
 
-	"github.com/sashabaranov/go-openai"
-
-	"github.com/kmesiab/chime-ai/ai/tools/transactions"
+	"github.com/kmesiab/chime-ai/config"
 	"github.com/kmesiab/chime-ai/database"
+	"github.com/kmesiab/chime-ai/importer"
 )
 
-var memory []openai.ChatCompletionMessage
+const usageText = `Usage: chime-ai <command> [flags]
+
+Commands:
+  import              import statements into the database
+  ask "<question>"    answer a single question and exit
+  chat                chat about your transactions, the default
+  report              show income and spending per month
+  export              write the transactions as CSV or JSON
+  merchants           list merchants and edit the rules that name them
+  categories          list spending categories and edit their rules
+  db migrate          create or update the database schema
+  db status           list the schema migrations and when they ran
+
+Run chime-ai <command> -help to see the flags of a command.
+`
+
+// command runs a subcommand given the loaded config and the arguments after
+// the command's name
+type command func(cfg *config.Config, args []string) error
+
+var commands = map[string]command{
+	"import":     importer.Run,
+	"ask":        askCommand,
+	"chat":       chatCommand,
+	"report":     reportCommand,
+	"export":     exportCommand,
+	"merchants":  merchantsCommand,
+	"categories": categoriesCommand,
+	"db":         dbCommand,
+}
 
 func main() {
 
-	ctx := context.Background()
-	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	var APIKEY = os.Getenv("OPENAI_API_KEY")
-	if APIKEY == "" {
-		log.Fatal("OPENAI_API_KEY environment variable not set")
-	}
-
-	var (
-		sqlDB *sql.DB
-		db    *gorm.DB
-		err   error
-	)
-
-	// Get the database connection
-	if db, err = database.GetDBConnection(); err != nil {
-		log.Printf("Error connecting to database: %v\n", err)
-		return
+	// Without a command, or with only flags, chime-ai chats
+	name, args := "chat", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	// Clean up the database connection
-	if sqlDB, err = db.DB(); err != nil {
-		log.Printf("Error getting generic database object: %v\n", err)
+	if name == "help" {
+		fmt.Print(usageText)
 		return
-	} else {
-		defer sqlDB.Close()
 	}
 
-	repository := database.NewTransactionRepository(db)
-
-	client := openai.NewClient(APIKEY)
-
-	memory = []openai.ChatCompletionMessage{
-		{
-			Role: openai.ChatMessageRoleSystem,
-			Content: `You are a financial advisor and a SQL expert with access to a transaction
-				history database via tools and can query it for more robust data and analysis. You use database results to make 
-				informed responses to help the user.`,
-		},
-		{
-			Role:    openai.ChatMessageRoleUser,
-			Content: "How has my spending changed month over month and give me a summary",
-		},
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", name, usageText)
+		os.Exit(2)
 	}
 
-	completionRequest := openai.ChatCompletionRequest{
-		Model:    openai.GPT4o,
-		Messages: memory,
-		Tools:    []openai.Tool{transactions.NewTool()},
-	}
-
-	resp, err := client.CreateChatCompletion(timeoutCtx, completionRequest)
-
+	cfg, err := config.Load(args)
 	if err != nil {
-		log.Printf("Error creating chat completion: %v\n", err)
-		return
+		log.Fatalf("Error loading config: %v", err)
 	}
 
-	topChoice := resp.Choices[0]
-
-	if len(topChoice.Message.ToolCalls) > 0 {
-
-		analysis, err := processAnalysis(ctx, topChoice, client, repository)
-
-		if err != nil || analysis == "" {
-			fmt.Printf("An error occurred while processing the analysis:\n%v\n", err)
-		}
-
-		fmt.Println("Final response:")
-		fmt.Println(analysis)
-		fmt.Println("\n--------\nAnalysis:")
-
-	} else {
-
-		fmt.Println("No data required to make this analysis:")
-		fmt.Println(topChoice.Message.Content)
+	if err := run(cfg, args); err != nil {
+		log.Fatalf("Error: %v", err)
 	}
 }
 
-type ToolResponse struct {
-	SQL string `json:"sql"`
-}
-
-func processAnalysis(ctx context.Context, topChoice openai.ChatCompletionChoice, client *openai.Client, repository *database.TransactionRepository) (string, error) {
+// dbCommand runs the database maintenance subcommands: migrate, which
+// brings the schema up to date or to a given version, and status, which
+// lists the migrations
+func dbCommand(cfg *config.Config, args []string) error {
+	if len(args) == 0 || (args[0] != "migrate" && args[0] != "status") {
+		return errors.New("usage: chime-ai db migrate|status [flags]")
+	}
 
-	var (
-		err                error
-		completionResponse openai.ChatCompletionResponse
-	)
+	fs := flag.NewFlagSet("chime-ai db "+args[0], flag.ExitOnError)
+	cfg.DatabaseFlags(fs)
 
-	toolOutput, err := processToolCalls(topChoice, repository)
+	var version int
+	if args[0] == "migrate" {
+		fs.IntVar(&version, "to", database.LatestVersion(), "Schema version to migrate to, reverting newer migrations")
+	}
+	_ = fs.Parse(args[1:])
 
+	db, err := database.Open(cfg.Database)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
+	defer closeDB(db)
 
-	if strings.TrimSpace(toolOutput) != "" {
-
-		memory = append(memory, openai.ChatCompletionMessage{
-			Role:    openai.ChatMessageRoleUser,
-			Content: toolOutput + " is there any further information you need to query for?",
-		})
-
-		completionRequest := openai.ChatCompletionRequest{
-			Model:            openai.GPT4oMini,
-			Messages:         memory,
-			Temperature:      0.7,
-			FrequencyPenalty: 0.7,
-			Tools:            []openai.Tool{transactions.NewTool()},
-		}
-
-		if completionResponse, err = client.CreateChatCompletion(ctx, completionRequest); err != nil {
-
-			return "", fmt.Errorf("Error creating chat completion: %v\n", err)
+	if args[0] == "migrate" {
+		if err := database.MigrateTo(db, version); err != nil {
+			return err
 		}
+	}
 
-		// If there is another tool call, recursively process it
-		if completionResponse.Choices[0].FinishReason == "tool_call" {
+	statuses, err := database.ListMigrations(db)
+	if err != nil {
+		return err
+	}
 
-			return processToolCalls(completionResponse.Choices[0], repository)
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = status.AppliedAt.Local().Format("2006-01-02 15:04")
 		}
 
+		fmt.Printf("%4d  %-16s  %s\n", status.Version, applied, status.Name)
 	}
 
-	return completionResponse.Choices[0].Message.Content, nil
+	return nil
 }
 
-func processToolCalls(topChoice openai.ChatCompletionChoice, repository *database.TransactionRepository) (string, error) {
-
-	var (
-		err           error
-		output        []map[string]interface{}
-		queryResponse []byte
-		toolResults   = "The financial information you requested:\n\n"
-	)
-
-	for _, call := range topChoice.Message.ToolCalls {
-		if call.Function.Name == "TransactionsTool" {
-
-			var toolResponse ToolResponse
-			if err = json.Unmarshal([]byte(call.Function.Arguments), &toolResponse); err != nil {
-				return "", fmt.Errorf("Invalid tool arguments: %v\n", err)
-			}
-
-			fmt.Printf("Executing SQL query: %s\n", toolResponse.SQL)
-
-			if output, err = repository.ExecuteRawQuery(toolResponse.SQL); err != nil {
-				return "", fmt.Errorf("Error executing SQL query: %v\n", err)
-			}
-
-			if queryResponse, err = json.MarshalIndent(output, "", "   "); err != nil {
-
-				return "", fmt.Errorf("Error marshaling query results: %v\n", err)
-			}
-
-			toolResults += string(queryResponse) + "\n\n"
-		}
+// closeDB closes the connection pool behind db
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		_ = sqlDB.Close()
 	}
-
-	return toolResults, nil
 }
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kmesiab/chime-ai/config"
+	"github.com/kmesiab/chime-ai/database"
+)
+
+const merchantsUsage = `usage: chime-ai merchants <action> [flags]
+
+Actions:
+  list                     list merchants by transaction count
+  rules                    list the rules, in the order they are tried
+  add-rule -pattern <re> -merchant <name> [-priority <n>]
+                           map descriptions matching a regular expression to a merchant
+  remove-rule <id>         delete a rule
+  test "<description>"     show the merchant a description maps to
+  backfill                 reassign the merchant of every transaction`
+
+// merchantsCommand lists merchants and edits the rules that map
+// descriptions to them. Changing a rule reassigns every transaction.
+func merchantsCommand(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(merchantsUsage)
+	}
+
+	action := args[0]
+	switch action {
+	case "list", "rules", "add-rule", "remove-rule", "test", "backfill":
+	default:
+		return fmt.Errorf("unknown action %q\n\n%s", action, merchantsUsage)
+	}
+
+	fs := flag.NewFlagSet("chime-ai merchants "+action, flag.ExitOnError)
+	cfg.DatabaseFlags(fs)
+
+	var rule database.MerchantRule
+	if action == "add-rule" {
+		fs.StringVar(&rule.Pattern, "pattern", "", "Case-insensitive regular expression matched against descriptions")
+		fs.StringVar(&rule.Merchant, "merchant", "", "Merchant the matching descriptions belong to")
+		fs.IntVar(&rule.Priority, "priority", 0, "Rules with a higher priority are tried first")
+	}
+	_ = fs.Parse(args[1:])
+
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer closeDB(db)
+
+	if err := database.Migrate(db); err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		return writeMerchants(db)
+
+	case "rules":
+		return writeMerchantRules(db)
+
+	case "add-rule":
+		if err := database.AddMerchantRule(db, &rule); err != nil {
+			return err
+		}
+		fmt.Printf("Added rule %d.\n", rule.ID)
+
+	case "remove-rule":
+		id, err := strconv.ParseUint(fs.Arg(0), 10, 0)
+		if err != nil {
+			return fmt.Errorf("expected a rule ID from chime-ai merchants rules, got %q", fs.Arg(0))
+		}
+		if err := database.DeleteMerchantRule(db, uint(id)); err != nil {
+			return err
+		}
+		fmt.Printf("Removed rule %d.\n", id)
+
+	case "test":
+		normalizer, err := database.NewMerchantNormalizer(db)
+		if err != nil {
+			return err
+		}
+
+		description := strings.Join(fs.Args(), " ")
+		fmt.Printf("%s -> %s\n", description, normalizer.Normalize(description))
+		return nil
+	}
+
+	// The rules changed, or a backfill was asked for
+	changed, err := database.AssignMerchants(db, false)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Reassigned the merchant of %d transactions.\n", changed)
+
+	return nil
+}
+
+func writeMerchants(db *gorm.DB) error {
+	counts, err := database.NewTransactionRepository(db).TransactionCountByMerchant(time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	for _, count := range counts {
+		fmt.Printf("%6d  %s\n", count.TotalTransactions, count.Description)
+	}
+
+	return nil
+}
+
+func writeMerchantRules(db *gorm.DB) error {
+	rules, err := database.ListMerchantRules(db)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		fmt.Printf("%4d  priority %-3d  %-30s  %s\n", rule.ID, rule.Priority, rule.Merchant, rule.Pattern)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kmesiab/chime-ai/config"
+	"github.com/kmesiab/chime-ai/database"
+)
+
+// reportCommand prints income and spending per month
+func reportCommand(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("chime-ai report", flag.ExitOnError)
+	cfg.DatabaseFlags(fs)
+
+	var period dateRange
+	period.flags(fs)
+	_ = fs.Parse(args)
+
+	db, err := database.OpenReadOnly(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer closeDB(db)
+
+	if err := database.CheckVersion(db); err != nil {
+		return err
+	}
+
+	return writeReport(os.Stdout, db, period)
+}
+
+// writeReport prints the income, spending and net of each month in period.
+// Transfers between the user's own accounts are neither.
+func writeReport(out io.Writer, db *gorm.DB, period dateRange) error {
+	from, to := period.bounds()
+
+	months, err := database.NewTransactionRepository(db).IncomeVsExpense(from, to)
+	if err != nil {
+		return err
+	}
+
+	if len(months) == 0 {
+		fmt.Fprintln(out, "No transactions found.")
+		return nil
+	}
+
+	total := database.IncomeExpense{Month: "Total"}
+
+	fmt.Fprintln(out, "Month        Income     Spending          Net  Transactions")
+	for _, month := range months {
+		writeMonth(out, month)
+
+		total.Income += month.Income
+		total.Expense += month.Expense
+		total.Transactions += month.Transactions
+	}
+	writeMonth(out, total)
+
+	return nil
+}
+
+func writeMonth(out io.Writer, month database.IncomeExpense) {
+	fmt.Fprintf(out, "%-7s %11s %12s %12s %13d\n",
+		month.Month, month.Income, month.Expense, month.Net(), month.Transactions)
+}
+
+// dateRange limits a command to transactions between two dates, inclusive.
+// Either end may be left open.
+type dateRange struct {
+	From, To dateValue
+}
+
+func (r *dateRange) flags(fs *flag.FlagSet) {
+	fs.Var(&r.From, "from", "First date to include, as YYYY-MM-DD")
+	fs.Var(&r.To, "to", "Last date to include, as YYYY-MM-DD")
+}
+
+// bounds returns the range as the repository takes it, with an exclusive
+// end
+func (r dateRange) bounds() (time.Time, time.Time) {
+	to := r.To.Time
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1)
+	}
+
+	return r.From.Time, to
+}
+
+// apply adds the range to a query of the ledger
+func (r dateRange) apply(query *gorm.DB) *gorm.DB {
+	from, to := r.bounds()
+
+	if !from.IsZero() {
+		query = query.Where("date >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("date < ?", to)
+	}
+
+	return query
+}
+
+// dateValue is a flag.Value for dates
+type dateValue struct {
+	time.Time
+}
+
+func (d *dateValue) String() string {
+	if d == nil || d.IsZero() {
+		return ""
+	}
+
+	return d.Format(time.DateOnly)
+}
+
+func (d *dateValue) Set(s string) error {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return fmt.Errorf("expected a date as YYYY-MM-DD, got %q", s)
+	}
+
+	d.Time = t
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kmesiab/chime-ai/database"
+)
+
+// newLedgerDB returns a migrated database holding a paycheck, two purchases
+// and a transfer to savings in July, and a purchase in August
+func newLedgerDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "transactions.db"))
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	t.Cleanup(func() { closeDB(db) })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	transferID := uint(99)
+	date := func(day int, month time.Month) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	rows := []database.Transaction{
+		{Date: date(1, time.July), Description: "Payroll", Type: "Deposit", Amount: 150000},
+		{Date: date(3, time.July), Description: "Coffee Shop", Type: "Purchase", Amount: -450},
+		{Date: date(9, time.July), Description: "Grocery Store", Type: "Purchase", Amount: -8230},
+		{Date: date(15, time.July), Description: "Transfer to Savings", Type: "Transfer", Amount: -20000, TransferID: &transferID},
+		{Date: date(2, time.August), Description: "Coffee Shop", Type: "Purchase", Amount: -500},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("failed to insert transactions: %v", err)
+	}
+
+	return db
+}
+
+func TestWriteReport(t *testing.T) {
+	db := newLedgerDB(t)
+
+	var out bytes.Buffer
+	if err := writeReport(&out, db, dateRange{}); err != nil {
+		t.Fatalf("writeReport returned an error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{
+		"2024-07     1500.00        86.80      1413.20             3",
+		"2024-08        0.00         5.00        -5.00             1",
+		"Total       1500.00        91.80      1408.20             4",
+	}
+
+	if len(lines) != len(want)+1 {
+		t.Fatalf("expected a header and %d rows, got:\n%s", len(want), out.String())
+	}
+
+	for i, line := range want {
+		if lines[i+1] != line {
+			t.Errorf("row %d = %q, want %q", i, lines[i+1], line)
+		}
+	}
+}
+
+func TestWriteReport_DateRange(t *testing.T) {
+	db := newLedgerDB(t)
+
+	var period dateRange
+	if err := period.From.Set("2024-08-01"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := writeReport(&out, db, period); err != nil {
+		t.Fatalf("writeReport returned an error: %v", err)
+	}
+
+	if strings.Contains(out.String(), "2024-07") || !strings.Contains(out.String(), "2024-08") {
+		t.Errorf("expected only August:\n%s", out.String())
+	}
+
+	if err := period.To.Set("2024-08"); err == nil {
+		t.Error("expected an error for a date without a day")
+	}
+}
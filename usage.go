@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/sashabaranov/go-openai"
+	"gorm.io/gorm"
+
+	"github.com/kmesiab/chime-ai/ai/usage"
+	"github.com/kmesiab/chime-ai/database"
+)
+
+var (
+	// tracker adds up the tokens and cost of the current conversation
+	tracker *usage.Tracker
+
+	// appDB is the read-write connection for the app's own tables: the
+	// usage of every model call and the conversation history
+	appDB *gorm.DB
+)
+
+// recordUsage adds a model call to the tracker and saves it to the database
+func recordUsage(model string, u openai.Usage) {
+	cost, priced := tracker.Record(model, u)
+
+	if appDB == nil {
+		return
+	}
+
+	err := appDB.Create(&database.Usage{
+		Model:            model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		Cost:             cost,
+		Priced:           priced,
+	}).Error
+	if err != nil {
+		log.Printf("Error saving usage: %v\n", err)
+	}
+}
+
+// writeUsage prints the usage of the conversation and the monthly totals
+// of every conversation so far
+func writeUsage(out io.Writer) {
+	if tracker == nil {
+		fmt.Fprintln(out, "Usage isn't being tracked.")
+		return
+	}
+
+	conversation := tracker.Conversation()
+	fmt.Fprintf(out, "This conversation: %d calls, %s\n", conversation.Calls, conversation)
+
+	if appDB == nil {
+		return
+	}
+
+	months, err := database.UsageByMonth(appDB)
+	if err != nil {
+		fmt.Fprintf(out, "Error reading usage: %v\n", err)
+		return
+	}
+
+	if len(months) > 0 {
+		fmt.Fprintln(out, "\nMonth      Calls  Prompt tokens  Completion tokens  Cost")
+	}
+	for _, month := range months {
+		fmt.Fprintf(out, "%-9s %6d %14d %18d  $%.4f\n",
+			month.Month, month.Calls, month.PromptTokens, month.CompletionTokens, month.Cost)
+	}
+}